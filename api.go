@@ -1,14 +1,16 @@
 package n26
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
 )
@@ -53,6 +55,14 @@ type Token struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	MfaToken     string `json:"mfaToken"`
+	// seconds the access token is valid for, as returned by the login
+	ExpiresIn int       `json:"expires_in,omitempty"`
+	Expiry    time.Time `json:"expiry,omitempty"`
+}
+
+// Valid reports whether the access token can still be used, with a minute to spare.
+func (t *Token) Valid() bool {
+	return t.AccessToken != "" && time.Now().Add(time.Minute).Before(t.Expiry)
 }
 
 type Statuses struct {
@@ -146,7 +156,7 @@ type Contacts []struct {
 	} `json:"account"`
 }
 
-type Transactions []struct {
+type Transaction struct {
 	ID                   string    `json:"id"`
 	UserID               string    `json:"userId"`
 	Type                 string    `json:"type"`
@@ -168,7 +178,7 @@ type Transactions []struct {
 	Pending              bool      `json:"pending"`
 	TransactionNature    string    `json:"transactionNature"`
 	CreatedTS            TimeStamp `json:"createdTS"`
-	MerchantCountry      int       `json:"merchantCountry,omitempty"`
+	MerchantCountry      Country   `json:"merchantCountry,omitempty"`
 	SmartLinkID          string    `json:"smartLinkId"`
 	LinkID               string    `json:"linkId"`
 	Confirmed            TimeStamp `json:"confirmed"`
@@ -183,6 +193,8 @@ type Transactions []struct {
 	SmartContactID       string    `json:"smartContactId,omitempty"`
 }
 
+type Transactions []Transaction
+
 type Statements []struct {
 	ID        string `json:"id"`
 	URL       string `json:"url"`
@@ -226,51 +238,96 @@ func (t *TokenSource) Token() (*oauth2.Token, error) {
 }
 
 func NewClient(a Auth) (*Client, error) {
+	token, err := Login(a)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithToken(token), nil
+}
+
+// Login retrieves an access token, after the login is approved on the paired device.
+// Store the token to create clients with NewClientWithToken until it expires.
+func Login(a Auth) (*Token, error) {
 	token := &Token{}
-	err := token.GetMFAToken(a.UserName, a.Password, a.DeviceToken)
-	check(err)
-	err = token.requestMfaApproval(a.DeviceToken)
-	check(err)
+	if err := token.GetMFAToken(a.UserName, a.Password, a.DeviceToken); err != nil {
+		return nil, err
+	}
+	if err := token.requestMfaApproval(a.DeviceToken); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("no access token received, the login was not approved on the paired device in time")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
 
+// NewClientWithToken creates a client using the access token of a previous login.
+func NewClientWithToken(token *Token) *Client {
 	tokenSource := &TokenSource{
 		AccessToken: token.AccessToken,
 	}
 	oauthClient := oauth2.NewClient(oauth2.NoContext, tokenSource)
-	return (*Client)(oauthClient), nil
+	return (*Client)(oauthClient)
 }
 
-func (c *Client) n26RawRequest(requestMethod, endpoint string, params map[string]string, callback func(io.Reader) error) error {
-	var req *http.Request
-	var err error
+// StatusError is returned for responses of the N26 API with a status other than 2xx.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	// the start of the response, usually a JSON error message
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("N26 API responded %s", e.Status)
+	}
+	return fmt.Sprintf("N26 API responded %s: %s", e.Status, e.Body)
+}
 
+// IsUnauthorized reports whether the error is a 401 response, as returned
+// for access tokens that expired or were revoked.
+func IsUnauthorized(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized
+}
+
+func newStatusError(res *http.Response) *StatusError {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 500))
+	return &StatusError{res.StatusCode, res.Status, strings.TrimSpace(string(body))}
+}
+
+func (c *Client) n26RawRequest(requestMethod, endpoint string, params map[string]string, callback func(io.Reader) error) error {
 	u, _ := url.ParseRequestURI(apiURL)
 	u.Path = endpoint
 	u.RawQuery = mapToQuery(params).Encode()
 
-	switch requestMethod {
-	case http.MethodGet:
-		req, err = http.NewRequest(http.MethodGet, u.String(), nil)
-		check(err)
-	case http.MethodPost:
-		req, err = http.NewRequest(http.MethodPost, u.String(), nil)
-		check(err)
+	req, err := http.NewRequest(requestMethod, u.String(), nil)
+	if err != nil {
+		return err
 	}
-
 	res, err := (*http.Client)(c).Do(req)
-	check(err)
+	if err != nil {
+		return err
+	}
 	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return newStatusError(res)
+	}
 	return callback(res.Body)
 }
 
-func (c *Client) n26Request(requestMethod, endpoint string, params map[string]string) []byte {
+func (c *Client) n26Request(requestMethod, endpoint string, params map[string]string) ([]byte, error) {
 	var body []byte
 	err := c.n26RawRequest(requestMethod, endpoint, params, func(r io.Reader) error {
 		var err error
 		body, err = ioutil.ReadAll(r)
 		return err
 	})
-	check(err)
-	return body
+	return body, err
 }
 
 func mapToQuery(params map[string]string) url.Values {
@@ -281,81 +338,88 @@ func mapToQuery(params map[string]string) url.Values {
 	return values
 }
 
-func (auth *Client) GetBalance(retType string) (string, *Balance) {
-	body := auth.n26Request(http.MethodGet, "/api/accounts", nil)
+func (auth *Client) GetBalance() (*Balance, error) {
+	body, err := auth.n26Request(http.MethodGet, "/api/accounts", nil)
+	if err != nil {
+		return nil, err
+	}
 	balance := &Balance{}
-	check(json.Unmarshal(body, &balance))
-	identedJSON, _ := json.MarshalIndent(&balance, "", "  ")
-	if retType == "json" {
-		return string(identedJSON), balance
+	if err := json.Unmarshal(body, &balance); err != nil {
+		return nil, err
 	}
-	return "", balance
+	return balance, nil
 }
 
-func (auth *Client) GetInfo(retType string) (string, *PersonalInfo) {
-	body := auth.n26Request(http.MethodGet, "/api/me", nil)
+func (auth *Client) GetInfo() (*PersonalInfo, error) {
+	body, err := auth.n26Request(http.MethodGet, "/api/me", nil)
+	if err != nil {
+		return nil, err
+	}
 	info := &PersonalInfo{}
-	check(json.Unmarshal(body, &info))
-	identedJSON, _ := json.MarshalIndent(&info, "", "  ")
-	if retType == "json" {
-		return string(identedJSON), info
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
 	}
-	return "", info
+	return info, nil
 }
 
-func (auth *Client) GetStatus(retType string) (string, *Statuses) {
-	body := auth.n26Request(http.MethodGet, "/api/me/statuses", nil)
+func (auth *Client) GetStatus() (*Statuses, error) {
+	body, err := auth.n26Request(http.MethodGet, "/api/me/statuses", nil)
+	if err != nil {
+		return nil, err
+	}
 	status := &Statuses{}
-	check(json.Unmarshal(body, &status))
-	identedJSON, _ := json.MarshalIndent(&status, "", "  ")
-	if retType == "json" {
-		return string(identedJSON), status
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, err
 	}
-	return "", status
+	return status, nil
 }
 
-func (auth *Client) GetAddresses(retType string) (string, *Addresses) {
-	body := auth.n26Request(http.MethodGet, "/api/addresses", nil)
+func (auth *Client) GetAddresses() (*Addresses, error) {
+	body, err := auth.n26Request(http.MethodGet, "/api/addresses", nil)
+	if err != nil {
+		return nil, err
+	}
 	addresses := &Addresses{}
-	check(json.Unmarshal(body, &addresses))
-	identedJSON, _ := json.MarshalIndent(&addresses, "", "  ")
-	if retType == "json" {
-		return string(identedJSON), addresses
+	if err := json.Unmarshal(body, &addresses); err != nil {
+		return nil, err
 	}
-	return "", addresses
+	return addresses, nil
 }
 
-func (auth *Client) GetCards(retType string) (string, *Cards) {
-	body := auth.n26Request(http.MethodGet, "/api/v2/cards", nil)
+func (auth *Client) GetCards() (*Cards, error) {
+	body, err := auth.n26Request(http.MethodGet, "/api/v2/cards", nil)
+	if err != nil {
+		return nil, err
+	}
 	cards := &Cards{}
-	check(json.Unmarshal(body, &cards))
-	identedJSON, _ := json.MarshalIndent(&cards, "", "  ")
-	if retType == "json" {
-		return string(identedJSON), cards
+	if err := json.Unmarshal(body, &cards); err != nil {
+		return nil, err
 	}
-	return "", cards
+	return cards, nil
 }
 
-func (auth *Client) GetLimits(retType string) (string, *Limits) {
-	body := auth.n26Request(http.MethodGet, "/api/settings/account/limits", nil)
+func (auth *Client) GetLimits() (*Limits, error) {
+	body, err := auth.n26Request(http.MethodGet, "/api/settings/account/limits", nil)
+	if err != nil {
+		return nil, err
+	}
 	limits := &Limits{}
-	check(json.Unmarshal(body, &limits))
-	identedJSON, _ := json.MarshalIndent(&limits, "", "  ")
-	if retType == "json" {
-		return string(identedJSON), limits
+	if err := json.Unmarshal(body, &limits); err != nil {
+		return nil, err
 	}
-	return "", limits
+	return limits, nil
 }
 
-func (auth *Client) GetContacts(retType string) (string, *Contacts) {
-	body := auth.n26Request(http.MethodGet, "/api/smrt/contacts", nil)
+func (auth *Client) GetContacts() (*Contacts, error) {
+	body, err := auth.n26Request(http.MethodGet, "/api/smrt/contacts", nil)
+	if err != nil {
+		return nil, err
+	}
 	contacts := &Contacts{}
-	check(json.Unmarshal(body, &contacts))
-	identedJSON, _ := json.MarshalIndent(&contacts, "", "  ")
-	if retType == "json" {
-		return string(identedJSON), contacts
+	if err := json.Unmarshal(body, &contacts); err != nil {
+		return nil, err
 	}
-	return "", contacts
+	return contacts, nil
 }
 
 func (auth *Client) GetLastTransactions(limit string) (*Transactions, error) {
@@ -364,17 +428,34 @@ func (auth *Client) GetLastTransactions(limit string) (*Transactions, error) {
 
 // Get transactions for the given time window.
 // Use the zero values for the time stamps if no restrictions are
-// desired (use the defaults on the server)
+// desired (use the defaults on the server). A zero end time with a
+// start time set means up to now.
 func (auth *Client) GetTransactions(from, to TimeStamp, limit string) (*Transactions, error) {
+	return auth.SearchTransactions(from, to, limit, "")
+}
+
+// Get transactions for the given time window that match the text.
+// The text search is done by N26 on partner and merchant names as well as the
+// reference text. An empty text matches all transactions.
+func (auth *Client) SearchTransactions(from, to TimeStamp, limit, text string) (*Transactions, error) {
 	params := map[string]string{
 		"limit": limit,
 	}
-	//Filter is applied only if both values are set
-	if !from.IsZero() && !to.IsZero() {
+	//Filter is applied only if the start is set, the end defaults to now
+	if !from.IsZero() {
+		if to.IsZero() {
+			to = TimeStamp{time.Now()}
+		}
 		params["from"] = fmt.Sprint(from.AsMillis())
 		params["to"] = fmt.Sprint(to.AsMillis())
 	}
-	body := auth.n26Request(http.MethodGet, "/api/smrt/transactions", params)
+	if text != "" {
+		params["textFilter"] = text
+	}
+	body, err := auth.n26Request(http.MethodGet, "/api/smrt/transactions", params)
+	if err != nil {
+		return nil, err
+	}
 	transactions := &Transactions{}
 	if err := json.Unmarshal(body, &transactions); err != nil {
 		return nil, err
@@ -383,57 +464,79 @@ func (auth *Client) GetTransactions(from, to TimeStamp, limit string) (*Transact
 }
 
 // Get transactions for the given time window as N26 CSV file. Stored as 'smrt_statement.csv'
+// The start time must be set, the end time defaults to now.
 func (auth *Client) GetSmartStatementCsv(from, to TimeStamp, reader func(io.Reader) error) error {
-	//Filter is applied only if both values are set
-	if from.IsZero() || to.IsZero() {
-		return errors.New("Start and end time must be set")
+	if from.IsZero() {
+		return errors.New("Start time must be set")
+	}
+	if to.IsZero() {
+		to = TimeStamp{time.Now()}
 	}
 	return auth.n26RawRequest(http.MethodGet, fmt.Sprintf("/api/smrt/reports/%v/%v/statements", from.AsMillis(), to.AsMillis()), nil, reader)
 }
 
-func (auth *Client) GetStatements(retType string) (string, *Statements) {
-	body := auth.n26Request(http.MethodGet, "/api/statements", nil)
+func (auth *Client) GetStatements() (*Statements, error) {
+	body, err := auth.n26Request(http.MethodGet, "/api/statements", nil)
+	if err != nil {
+		return nil, err
+	}
 	statements := &Statements{}
-	check(json.Unmarshal(body, &statements))
-	identedJSON, _ := json.MarshalIndent(&statements, "", "  ")
-	if retType == "json" {
-		return string(identedJSON), statements
+	if err := json.Unmarshal(body, &statements); err != nil {
+		return nil, err
 	}
-	return "", statements
+	return statements, nil
 }
 
-func (auth *Client) GetStatementPDF(ID string) {
-	body := auth.n26Request(http.MethodGet, fmt.Sprintf("/api/statements/%s", ID), nil)
-	ioutil.WriteFile(
-		fmt.Sprintf("%s.pdf", ID),
-		body,
-		0750,
-	)
+// GetStatementPDF retrieves the PDF file of the statement. The caller must close it.
+// Responses other than a PDF file, like error pages, are returned as error.
+func (auth *Client) GetStatementPDF(ID string) (io.ReadCloser, error) {
+	u, _ := url.ParseRequestURI(apiURL)
+	u.Path = fmt.Sprintf("/api/statements/%s", ID)
+	res, err := (*http.Client)(auth).Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		return nil, newStatusError(res)
+	}
+	body := bufio.NewReader(res.Body)
+	magic, _ := body.Peek(len(pdfMagic))
+	if string(magic) != pdfMagic {
+		defer res.Body.Close()
+		message, _ := ioutil.ReadAll(io.LimitReader(body, 200))
+		return nil, fmt.Errorf("statement %s is not available as PDF: %s, %s %s",
+			ID, res.Status, res.Header.Get("Content-Type"), strings.TrimSpace(string(message)))
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{body, res.Body}, nil
 }
 
-func (auth *Client) BlockCard(ID string) {
-	_ = auth.n26Request(http.MethodPost, fmt.Sprintf("/api/cards/%s/block", ID), nil)
-	fmt.Printf("\nYour card with ID: %s is DISABLED\n\n", ID)
-}
+// Every PDF file starts with this
+const pdfMagic = "%PDF-"
 
-func (auth *Client) UnblockCard(ID string) {
-	_ = auth.n26Request(http.MethodPost, fmt.Sprintf("/api/cards/%s/unblock", ID), nil)
-	fmt.Printf("\nYour card with ID: %s is ACTIVE\n\n", ID)
+// BlockCard blocks the card.
+func (auth *Client) BlockCard(ID string) error {
+	_, err := auth.n26Request(http.MethodPost, fmt.Sprintf("/api/cards/%s/block", ID), nil)
+	return err
 }
 
-func (auth *Client) GetSpaces(retType string) (string, *Spaces) {
-	body := auth.n26Request(http.MethodGet, "/api/spaces", nil)
-	spaces := &Spaces{}
-	check(json.Unmarshal(body, &spaces))
-	identedJSON, _ := json.MarshalIndent(&spaces, "", "  ")
-	if retType == "json" {
-		return string(identedJSON), spaces
-	}
-	return "", spaces
+// UnblockCard unblocks the card.
+func (auth *Client) UnblockCard(ID string) error {
+	_, err := auth.n26Request(http.MethodPost, fmt.Sprintf("/api/cards/%s/unblock", ID), nil)
+	return err
 }
 
-func check(e error) {
-	if e != nil {
-		log.Fatal(e.Error())
+func (auth *Client) GetSpaces() (*Spaces, error) {
+	body, err := auth.n26Request(http.MethodGet, "/api/spaces", nil)
+	if err != nil {
+		return nil, err
+	}
+	spaces := &Spaces{}
+	if err := json.Unmarshal(body, &spaces); err != nil {
+		return nil, err
 	}
+	return spaces, nil
 }
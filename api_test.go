@@ -0,0 +1,92 @@
+package n26
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenValid(t *testing.T) {
+	cases := []struct {
+		name  string
+		token Token
+		valid bool
+	}{
+		{"unexpired", Token{AccessToken: "a", Expiry: time.Now().Add(time.Hour)}, true},
+		{"expired", Token{AccessToken: "a", Expiry: time.Now().Add(-time.Hour)}, false},
+		{"expiring within a minute", Token{AccessToken: "a", Expiry: time.Now().Add(30 * time.Second)}, false},
+		{"without expiry", Token{AccessToken: "a"}, false},
+		{"without access token", Token{Expiry: time.Now().Add(time.Hour)}, false},
+	}
+	for _, c := range cases {
+		if valid := c.token.Valid(); valid != c.valid {
+			t.Errorf("%s: got valid %v, want %v", c.name, valid, c.valid)
+		}
+	}
+}
+
+func TestTokenStoredExpiry(t *testing.T) {
+	token := Token{AccessToken: "a", RefreshToken: "r", ExpiresIn: 1800, Expiry: time.Now().Add(time.Hour).Round(time.Second)}
+	content, err := json.Marshal(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := Token{}
+	if err := json.Unmarshal(content, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Expiry.Equal(token.Expiry) || !stored.Valid() {
+		t.Errorf("got %+v, want %+v", stored, token)
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// A client receiving the response for every request
+func respondingClient(status int, body string) *Client {
+	return &Client{Transport: roundTripper(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+func TestRequestStatus(t *testing.T) {
+	cases := []struct {
+		status       int
+		body         string
+		err          string
+		unauthorized bool
+	}{
+		{200, `{"iban":"DE1"}`, "", false},
+		{401, `{"error":"invalid_token"}`, `N26 API responded 401 Unauthorized: {"error":"invalid_token"}`, true},
+		{503, "", "N26 API responded 503 Service Unavailable", false},
+	}
+	for _, c := range cases {
+		balance, err := respondingClient(c.status, c.body).GetBalance()
+		if c.err == "" {
+			if err != nil || balance.IBAN != "DE1" {
+				t.Errorf("%d: got %+v, %v, want the balance", c.status, balance, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != c.err {
+			t.Errorf("%d: got error %v, want %s", c.status, err, c.err)
+		}
+		if IsUnauthorized(err) != c.unauthorized {
+			t.Errorf("%d: got unauthorized %v, want %v", c.status, IsUnauthorized(err), c.unauthorized)
+		}
+	}
+	if err := respondingClient(404, "").BlockCard("1"); err == nil {
+		t.Errorf("BlockCard: got no error for 404")
+	}
+}
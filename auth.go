@@ -35,21 +35,24 @@ func (t *Token) GetMFAToken(username, password, deviceToken string) error {
 
 	path := "/oauth2/token/"
 	req, err := createRequest(path, deviceToken, strings.NewReader(data.Encode()))
-	check(err)
+	if err != nil {
+		return err
+	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	res, err := http.DefaultClient.Do(req)
-	check(err)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 	if res.StatusCode != 403 {
 		return errors.New("Unexpected response from authentication request")
 	}
-	defer res.Body.Close()
 	body, err := ioutil.ReadAll(res.Body)
-	check(err)
-
-	err = json.Unmarshal(body, t)
-	check(err)
-	return nil
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, t)
 }
 
 func (t *Token) requestMfaApproval(deviceToken string) error {
@@ -57,25 +60,34 @@ func (t *Token) requestMfaApproval(deviceToken string) error {
 		"challengeType": "oob",
 		"mfaToken":      t.MfaToken,
 	})
-	check(err)
+	if err != nil {
+		return err
+	}
 
 	path := "/api/mfa/challenge"
 	req, err := createRequest(path, deviceToken, bytes.NewBuffer(data))
-	check(err)
+	if err != nil {
+		return err
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/59.0.3071.86 Safari/537.36")
 
 	res, err := http.DefaultClient.Do(req)
-	check(err)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
 	if res.StatusCode != 201 {
-		fmt.Println(res.StatusCode)
-		return errors.New("Failed to request MFA approval")
+		return fmt.Errorf("Failed to request MFA approval: %s", res.Status)
 	}
 
 	// retries 12 times every 5 seconds (60 seconds total wait time)
 	// until the login is approved in a authorized device (like the users phone)
 	for i := 0; i <= 12; i++ {
-		status := t.CompleteMfaApproval(deviceToken)
+		status, err := t.CompleteMfaApproval(deviceToken)
+		if err != nil {
+			return err
+		}
 		if status == 400 {
 			time.Sleep(5 * time.Second)
 		} else {
@@ -85,27 +97,33 @@ func (t *Token) requestMfaApproval(deviceToken string) error {
 	return nil
 }
 
-func (t *Token) CompleteMfaApproval(deviceToken string) int {
+func (t *Token) CompleteMfaApproval(deviceToken string) (int, error) {
 	data := url.Values{}
 	data.Set("grant_type", "mfa_oob")
 	data.Set("mfaToken", t.MfaToken)
 
 	path := "/oauth2/token"
 	req, err := createRequest(path, deviceToken, strings.NewReader(data.Encode()))
-	check(err)
+	if err != nil {
+		return 0, err
+	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	res, err := http.DefaultClient.Do(req)
-	check(err)
-	if res.StatusCode == 400 {
-		return res.StatusCode
+	if err != nil {
+		return 0, err
 	}
 	defer res.Body.Close()
+	if res.StatusCode == 400 {
+		return res.StatusCode, nil
+	}
 
 	body, err := ioutil.ReadAll(res.Body)
-	check(err)
-	err = json.Unmarshal(body, t)
-	check(err)
-
-	return res.StatusCode
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, t); err != nil {
+		return 0, err
+	}
+	return res.StatusCode, nil
 }
@@ -0,0 +1,315 @@
+package n26
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type camtDocument struct {
+	XMLName   xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02 Document"`
+	Statement struct {
+		Header struct {
+			MsgID   string `xml:"MsgId"`
+			Created string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Statement camtStatement `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	ID      string `xml:"Id"`
+	Created string `xml:"CreDtTm"`
+	FromTo  struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	} `xml:"FrToDt"`
+	Account struct {
+		ID       camtAccountID `xml:"Id"`
+		Currency string        `xml:"Ccy"`
+		Servicer *camtAgent    `xml:"Svcr,omitempty"`
+	} `xml:"Acct"`
+	Balances []camtBalance `xml:"Bal"`
+	Summary  struct {
+		Total   camtSummary `xml:"TtlNtries"`
+		Credits camtSummary `xml:"TtlCdtNtries"`
+		Debits  camtSummary `xml:"TtlDbtNtries"`
+	} `xml:"TxsSummry"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtAccountID struct {
+	IBAN  string       `xml:"IBAN,omitempty"`
+	Other *camtOtherID `xml:"Othr,omitempty"`
+}
+
+type camtOtherID struct {
+	ID string `xml:"Id"`
+}
+
+type camtAgent struct {
+	BIC string `xml:"FinInstnId>BIC"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type   string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount camtAmount `xml:"Amt"`
+	Sign   string     `xml:"CdtDbtInd"`
+	Date   string     `xml:"Dt>Dt"`
+}
+
+type camtSummary struct {
+	Count string `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+	Net   string `xml:"TtlNetNtryAmt,omitempty"`
+	Sign  string `xml:"CdtDbtInd,omitempty"`
+}
+
+type camtEntry struct {
+	Reference   string                `xml:"NtryRef"`
+	Amount      camtAmount            `xml:"Amt"`
+	Sign        string                `xml:"CdtDbtInd"`
+	Status      string                `xml:"Sts"`
+	BookingDate string                `xml:"BookgDt>Dt"`
+	ValueDate   string                `xml:"ValDt>Dt"`
+	ServicerRef string                `xml:"AcctSvcrRef,omitempty"`
+	Code        camtBankCode          `xml:"BkTxCd"`
+	Details     camtTransactionDetail `xml:"NtryDtls>TxDtls"`
+}
+
+type camtBankCode struct {
+	Domain struct {
+		Code   string `xml:"Cd"`
+		Family struct {
+			Code    string `xml:"Cd"`
+			SubCode string `xml:"SubFmlyCd"`
+		} `xml:"Fmly"`
+	} `xml:"Domn"`
+	Proprietary *camtProprietaryCode `xml:"Prtry,omitempty"`
+}
+
+type camtProprietaryCode struct {
+	Code string `xml:"Cd"`
+}
+
+type camtTransactionDetail struct {
+	References struct {
+		ServicerRef string `xml:"AcctSvcrRef"`
+	} `xml:"Refs"`
+	Parties    *camtParties    `xml:"RltdPties,omitempty"`
+	Agents     *camtAgents     `xml:"RltdAgts,omitempty"`
+	Remittance *camtRemittance `xml:"RmtInf,omitempty"`
+}
+
+type camtParties struct {
+	Debtor          *camtParty   `xml:"Dbtr,omitempty"`
+	DebtorAccount   *camtAccount `xml:"DbtrAcct,omitempty"`
+	Creditor        *camtParty   `xml:"Cdtr,omitempty"`
+	CreditorAccount *camtAccount `xml:"CdtrAcct,omitempty"`
+}
+
+type camtAgents struct {
+	DebtorAgent   *camtAgent `xml:"DbtrAgt,omitempty"`
+	CreditorAgent *camtAgent `xml:"CdtrAgt,omitempty"`
+}
+
+type camtRemittance struct {
+	Unstructured []string `xml:"Ustrd"`
+}
+
+type camtParty struct {
+	Name string `xml:"Nm"`
+}
+
+type camtAccount struct {
+	ID camtAccountID `xml:"Id"`
+}
+
+var (
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[a-zA-Z0-9]{1,30}$`)
+	bicPattern  = regexp.MustCompile(`^[A-Z]{6}[A-Z2-9][A-NP-Z0-9]([A-Z0-9]{3})?$`)
+)
+
+// Bank transaction codes (domain, family, sub family) for the N26 transaction types
+func camtBankTransactionCode(t Transaction) (string, string, string) {
+	switch {
+	case t.Mcc == 6011:
+		return "PMNT", "CCRD", "CWDL"
+	case t.Type == "PT" || t.Type == "AA":
+		return "PMNT", "CCRD", "POSD"
+	case t.Type == "CT":
+		return "PMNT", "RCDT", "ESCT"
+	case t.Type == "DT":
+		return "PMNT", "ICDT", "ESCT"
+	case t.Type == "DD":
+		return "PMNT", "RDDT", "ESDD"
+	case t.Type == "PF":
+		return "ACMT", "MDOP", "CHRG"
+	case t.Amount < 0:
+		return "PMNT", "MDOP", "OTHR"
+	}
+	return "PMNT", "MCOP", "OTHR"
+}
+
+func camtAccountFor(iban string) *camtAccount {
+	iban = strings.ToUpper(strings.Replace(iban, " ", "", -1))
+	if iban == "" {
+		return nil
+	}
+	if ibanPattern.MatchString(iban) {
+		return &camtAccount{camtAccountID{IBAN: iban}}
+	}
+	return &camtAccount{camtAccountID{Other: &camtOtherID{truncateText(iban, 34)}}}
+}
+
+func camtAgentFor(bic string) *camtAgent {
+	bic = strings.ToUpper(strings.TrimSpace(bic))
+	if !bicPattern.MatchString(bic) {
+		return nil
+	}
+	return &camtAgent{bic}
+}
+
+// Truncate to at most n characters, as ISO 20022 limits the length of texts
+func truncateText(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+func camtAmountOf(amount float64, currency string) camtAmount {
+	return camtAmount{currency, strconv.FormatFloat(math.Abs(amount), 'f', 2, 64)}
+}
+
+func creditDebit(amount float64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// Entry reference from the N26 transaction ID. The UUID is too long for the
+// 35 characters allowed, without dashes it fits.
+func camtReference(id string) string {
+	return truncateText(strings.Replace(id, "-", "", -1), 35)
+}
+
+func (s AccountStatement) camtEntry(t Transaction) camtEntry {
+	day := t.VisibleTS.In(loc).Format(dateFormat)
+	entry := camtEntry{
+		Reference:   camtReference(t.ID),
+		Amount:      camtAmountOf(t.Amount, s.Currency),
+		Sign:        creditDebit(t.Amount),
+		Status:      "BOOK",
+		BookingDate: day,
+		ValueDate:   day,
+		ServicerRef: camtReference(t.ID),
+	}
+	if t.Pending {
+		entry.Status = "PDNG"
+	}
+	domain, family, subFamily := camtBankTransactionCode(t)
+	entry.Code.Domain.Code = domain
+	entry.Code.Domain.Family.Code = family
+	entry.Code.Domain.Family.SubCode = subFamily
+	if t.Type != "" {
+		entry.Code.Proprietary = &camtProprietaryCode{t.Type}
+	}
+	entry.Details.References.ServicerRef = camtReference(t.ID)
+
+	name := t.PartnerName
+	if name == "" {
+		name = t.MerchantName
+	}
+	party := &camtParty{truncateText(name, 140)}
+	account := camtAccountFor(t.PartnerIban)
+	agent := camtAgentFor(t.PartnerBic)
+	if name != "" || account != nil {
+		entry.Details.Parties = &camtParties{}
+		if name == "" {
+			party = nil
+		}
+		// the counterparty pays for credits and receives debits
+		if t.Amount < 0 {
+			entry.Details.Parties.Creditor, entry.Details.Parties.CreditorAccount = party, account
+		} else {
+			entry.Details.Parties.Debtor, entry.Details.Parties.DebtorAccount = party, account
+		}
+	}
+	if agent != nil {
+		entry.Details.Agents = &camtAgents{}
+		if t.Amount < 0 {
+			entry.Details.Agents.CreditorAgent = agent
+		} else {
+			entry.Details.Agents.DebtorAgent = agent
+		}
+	}
+	if t.ReferenceText != "" {
+		entry.Details.Remittance = &camtRemittance{[]string{truncateText(t.ReferenceText, 140)}}
+	}
+	return entry
+}
+
+// WriteCamt053 writes the statement as ISO 20022 camt.053.001.02 bank to customer statement.
+func (s AccountStatement) WriteCamt053(w io.Writer) error {
+	now := time.Now().In(loc)
+	from, to := s.From.In(loc), s.To.In(loc)
+	doc := camtDocument{}
+	doc.Statement.Header.MsgID = fmt.Sprintf("N26-%s", now.Format("20060102150405"))
+	doc.Statement.Header.Created = now.Format(time.RFC3339)
+
+	statement := &doc.Statement.Statement
+	statement.ID = fmt.Sprintf("STMT-%s-%s", from.Format("20060102"), to.Format("20060102"))
+	statement.Created = now.Format(time.RFC3339)
+	statement.FromTo.From = from.Format(time.RFC3339)
+	statement.FromTo.To = to.Format(time.RFC3339)
+	if account := camtAccountFor(s.Account.IBAN); account != nil {
+		statement.Account.ID = account.ID
+	}
+	statement.Account.Currency = s.Currency
+	statement.Account.Servicer = camtAgentFor(s.Account.BIC)
+	statement.Balances = []camtBalance{
+		{"OPBD", camtAmountOf(s.Opening, s.Currency), creditDebit(s.Opening), from.Format(dateFormat)},
+		{"CLBD", camtAmountOf(s.Closing, s.Currency), creditDebit(s.Closing), to.Format(dateFormat)},
+	}
+
+	var credits, debits Transactions
+	for _, transaction := range s.Transactions {
+		if transaction.Amount < 0 {
+			debits = append(debits, transaction)
+		} else {
+			credits = append(credits, transaction)
+		}
+		statement.Entries = append(statement.Entries, s.camtEntry(transaction))
+	}
+	net := s.Transactions.Sum()
+	summary := &statement.Summary
+	summary.Total = camtSummary{strconv.Itoa(len(s.Transactions)),
+		strconv.FormatFloat(credits.Sum()-debits.Sum(), 'f', 2, 64),
+		strconv.FormatFloat(math.Abs(net), 'f', 2, 64), creditDebit(net)}
+	summary.Credits = camtSummary{Count: strconv.Itoa(len(credits)), Sum: strconv.FormatFloat(credits.Sum(), 'f', 2, 64)}
+	summary.Debits = camtSummary{Count: strconv.Itoa(len(debits)), Sum: strconv.FormatFloat(-debits.Sum(), 'f', 2, 64)}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,96 @@
+package n26
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAccountStatement() AccountStatement {
+	return AccountStatement{
+		Account:  &Balance{IBAN: "DE74100110012620000000", BIC: "NTSBDEB1XXX"},
+		Currency: "EUR",
+		From:     time.Date(2018, 3, 1, 0, 0, 0, 0, loc),
+		To:       EndOfDay(time.Date(2018, 3, 31, 0, 0, 0, 0, loc)),
+		Opening:  100,
+		Closing:  1537.51,
+		Transactions: Transactions{
+			{ID: "6c1f2a5e-2f1b-4a2e-9d2f-0f7b2b1c3d4e", Type: "CT", Amount: 1500, CurrencyCode: "EUR",
+				VisibleTS: TimeStamp{time.Date(2018, 3, 1, 9, 0, 0, 0, loc)}, PartnerName: "ACME GmbH",
+				PartnerIban: "DE89 3704 0044 0532 0130 00", PartnerBic: "COBADEFFXXX", ReferenceText: "Salary 03/2018"},
+			{ID: "7d2f3b6f-3a2c-5b3f-ae30-1a8c3c2d4e5f", Type: "PT", Amount: -12.49, CurrencyCode: "EUR",
+				VisibleTS: TimeStamp{time.Date(2018, 3, 17, 17, 43, 44, 0, loc)}, MerchantName: "REWE & Co", Pending: true},
+			{ID: "8e3a4c7a-4b3d-6c4a-bf41-2b9d4d3e5f6a", Type: "XX", Amount: 0, CurrencyCode: "EUR",
+				VisibleTS: TimeStamp{time.Date(2018, 3, 31, 23, 0, 0, 0, loc)}, PartnerIban: "not an iban"},
+		},
+	}
+}
+
+func TestCamtStatement(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	if err := testAccountStatement().WriteCamt053(buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc camtDocument
+	if err := xml.Unmarshal(buffer.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	statement := doc.Statement.Statement
+	if statement.ID != "STMT-20180301-20180331" {
+		t.Errorf("Unexpected statement ID %q", statement.ID)
+	}
+	if len(statement.Balances) != 2 || statement.Balances[0].Amount.Value != "100.00" || statement.Balances[1].Type != "CLBD" {
+		t.Errorf("Unexpected balances %+v", statement.Balances)
+	}
+	if len(statement.Entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(statement.Entries))
+	}
+	credit, debit := statement.Entries[0], statement.Entries[1]
+	if credit.Reference != "6c1f2a5e2f1b4a2e9d2f0f7b2b1c3d4e" || credit.Sign != "CRDT" || credit.Amount.Value != "1500.00" {
+		t.Errorf("Unexpected credit entry %+v", credit)
+	}
+	if credit.Details.Parties.DebtorAccount.ID.IBAN != "DE89370400440532013000" || credit.Details.Agents.DebtorAgent.BIC != "COBADEFFXXX" {
+		t.Errorf("Unexpected counterparty %+v %+v", credit.Details.Parties, credit.Details.Agents)
+	}
+	if debit.Sign != "DBIT" || debit.Status != "PDNG" || debit.Details.Parties.Creditor.Name != "REWE & Co" {
+		t.Errorf("Unexpected debit entry %+v", debit)
+	}
+	if statement.Summary.Total.Sum != "1512.49" || statement.Summary.Total.Net != "1487.51" || statement.Summary.Total.Sign != "CRDT" {
+		t.Errorf("Unexpected summary %+v", statement.Summary.Total)
+	}
+}
+
+// Validate against the schema in testdata if xmllint is available. It is a
+// hand-written subset of camt.053.001.02 with the elements the export writes,
+// so this checks their structure, order and types but is no proof that the
+// document is valid against the official ISO 20022 schema.
+func TestCamtStatementSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not available")
+	}
+	dir, err := ioutil.TempDir("", "camt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "statement.xml")
+	buffer := &bytes.Buffer{}
+	if err := testAccountStatement().WriteCamt053(buffer); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, buffer.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(xmllint, "--noout", "--schema", "testdata/camt.053.001.02-subset.xsd", file).CombinedOutput()
+	if err != nil {
+		t.Errorf("Statement does not validate: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+}
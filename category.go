@@ -0,0 +1,40 @@
+package n26
+
+import "strings"
+
+const categoryPrefix = "micro-v2-"
+
+// Display names of the N26 transaction categories
+var categoryNames = map[string]string{
+	"atm":                     "ATM",
+	"bars-restaurants":        "Bars & Restaurants",
+	"business":                "Business",
+	"cash26":                  "CASH26",
+	"education":               "Education",
+	"family-friends":          "Family & Friends",
+	"food-groceries":          "Food & Groceries",
+	"healthcare-drugstores":   "Healthcare & Drug Stores",
+	"household-utilities":     "Household & Utilities",
+	"income":                  "Income",
+	"insurances-finances":     "Insurances & Finances",
+	"leisure-entertainment":   "Leisure & Entertainment",
+	"media-electronics":       "Media & Electronics",
+	"miscellaneous":           "Miscellaneous",
+	"savings-investments":     "Savings & Investments",
+	"shopping":                "Shopping",
+	"subscriptions-donations": "Subscriptions & Donations",
+	"tax-fines":               "Tax & Fines",
+	"transport-car":           "Transport & Car",
+	"travel-holidays":         "Travel & Holidays",
+}
+
+// CategoryName returns a display name for the N26 category of the
+// transaction, e.g. 'Food & Groceries' for 'micro-v2-food-groceries'.
+// Unknown categories are shown as their ID without prefix.
+func (t Transaction) CategoryName() string {
+	id := strings.TrimPrefix(t.Category, categoryPrefix)
+	if name, ok := categoryNames[id]; ok {
+		return name
+	}
+	return id
+}
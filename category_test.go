@@ -0,0 +1,17 @@
+package n26
+
+import "testing"
+
+func TestCategoryName(t *testing.T) {
+	cases := map[string]string{
+		"micro-v2-food-groceries": "Food & Groceries",
+		"micro-v2-atm":            "ATM",
+		"micro-v2-something-new":  "something-new",
+		"":                        "",
+	}
+	for category, want := range cases {
+		if got := (Transaction{Category: category}).CategoryName(); got != want {
+			t.Errorf("CategoryName(%q) = %q, want %q", category, got, want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+var allProfilesFlag = cli.BoolFlag{Name: "all-profiles", Usage: "merge the accounts of all profiles of the config file, " +
+	"logging into them concurrently"}
+
+// The logged in account of a profile
+type account struct {
+	name    string
+	profile *profile
+	API     *n26.Client
+}
+
+// Log into the accounts of all profiles concurrently. Stored access tokens
+// are reused, and credentials are prompted for one profile after the other.
+// Profiles failing to log in are reported and left out.
+func profileAccounts() ([]account, error) {
+	cfg, err := readConfig(configPath())
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.profiles) == 0 {
+		return nil, errors.New("no profiles in the config file, see 'n26 profile add'")
+	}
+	accounts := make([]account, len(cfg.profiles))
+	for i, p := range cfg.profiles {
+		accounts[i] = account{name: p.name, profile: p}
+	}
+	ok, err := eachAccount(accounts, func(i int, a account) (err error) {
+		accounts[i].API, err = authenticate(a.profile, false)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	loggedIn := make([]account, len(ok))
+	for j, i := range ok {
+		loggedIn[j] = accounts[i]
+	}
+	return loggedIn, nil
+}
+
+// Call f for each account concurrently, returning the indexes of the accounts
+// it succeeded for. An account whose access token is rejected, as a stored
+// one may be, logs in again for another try. Failing accounts are reported,
+// an error is returned only if all fail.
+func eachAccount(accounts []account, f func(i int, a account) error) ([]int, error) {
+	errs := make([]error, len(accounts))
+	var wg sync.WaitGroup
+	for i, a := range accounts {
+		wg.Add(1)
+		go func(i int, a account) {
+			defer wg.Done()
+			errs[i] = f(i, a)
+			if a.API == nil || !n26.IsUnauthorized(errs[i]) {
+				return
+			}
+			if a.API, errs[i] = login(a.profile, false); errs[i] != nil {
+				return
+			}
+			accounts[i].API = a.API
+			errs[i] = f(i, a)
+		}(i, a)
+	}
+	wg.Wait()
+	ok := []int{}
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[-] Left out profile %s: %v\n", accounts[i].name, err)
+		} else {
+			ok = append(ok, i)
+		}
+	}
+	if len(ok) == 0 {
+		return nil, errors.New("no profile succeeded")
+	}
+	return ok, nil
+}
+
+// The balance of an account with the total including its spaces
+type accountBalance struct {
+	Account string `json:"account"`
+	n26.Balance
+	TotalBalance float64 `json:"totalBalance"`
+}
+
+// The balances of all accounts and their sums
+type accountsBalance struct {
+	Accounts         []accountBalance `json:"accounts"`
+	AvailableBalance float64          `json:"availableBalance"`
+	TotalBalance     float64          `json:"totalBalance"`
+}
+
+var accountBalanceColumns = columnRegistry{columns: []column{
+	{"account", "Account", field("Account")},
+	{"iban", "IBAN", field("IBAN")},
+	{"available", "Available Balance", amountField("AvailableBalance", "EUR")},
+	{"usable", "Usable Balance", amountField("UsableBalance", "EUR")},
+	{"total", "Total Balance", amountField("TotalBalance", "EUR")},
+	{"bic", "BIC", field("BIC")},
+}, defaults: 5}
+
+func allProfilesBalance(c *cli.Context) error {
+	accounts, err := profileAccounts()
+	check(err)
+	fetched := make([]accountBalance, len(accounts))
+	ok, err := eachAccount(accounts, func(i int, a account) error {
+		balance, err := a.API.GetBalance()
+		if err != nil {
+			return err
+		}
+		spaces, err := a.API.GetSpaces()
+		if err != nil {
+			return err
+		}
+		fetched[i] = accountBalance{a.name, *balance, spaces.TotalBalance}
+		return nil
+	})
+	check(err)
+	balances := accountsBalance{Accounts: []accountBalance{}}
+	for _, i := range ok {
+		b := fetched[i]
+		balances.Accounts = append(balances.Accounts, b)
+		balances.AvailableBalance += b.AvailableBalance
+		balances.TotalBalance += b.TotalBalance
+	}
+	if err := renderTable(c, balances, balances.Accounts, accountBalanceColumns); err != nil {
+		return err
+	}
+	if tableOutput(c) {
+		s := columnSelectionFromFlags(c)
+		fmt.Printf("\nAvailable balance of all accounts: %s\n", s.cell(money{balances.AvailableBalance, "EUR"}))
+		fmt.Printf("Total balance of all accounts and spaces: %s\n\n", s.cell(money{balances.TotalBalance, "EUR"}))
+	}
+	return nil
+}
+
+// A transaction of an account
+type accountTransaction struct {
+	Account string `json:"account"`
+	n26.Transaction
+}
+
+// The columns of transactions led by their account
+func accountTransactionColumns(rates *n26.ExchangeRates) columnRegistry {
+	columns := columnRegistry{columns: []column{{"account", "Account", field("Account")}}}
+	transactions := transactionColumns(rates)
+	for _, c := range transactions.columns {
+		value := c.value
+		c.value = func(item reflect.Value) interface{} { return value(item.FieldByName("Transaction")) }
+		columns.columns = append(columns.columns, c)
+	}
+	columns.defaults = 1 + transactions.defaults
+	return columns
+}
+
+func allProfilesTransactions(c *cli.Context) error {
+	if len(c.StringSlice("import")) > 0 {
+		return cli.NewExitError("--import can't be combined with --all-profiles!", 1)
+	}
+	if err := checkFormatArgument(c); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	switch format := transactionFormat(c); format {
+	case "table", "csv", "tsv", "json", "yaml", "ndjson":
+	default:
+		return cli.NewExitError(fmt.Sprintf("--all-profiles supports table, csv, tsv, json, yaml and ndjson, not %s!", format), 1)
+	}
+	if _, _, err := transactionRange(c); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	rates, err := readRatesFlag(c)
+	check(err)
+	accounts, err := profileAccounts()
+	check(err)
+	results := make([]*n26.Transactions, len(accounts))
+	ok, err := eachAccount(accounts, func(i int, a account) (err error) {
+		results[i], err = fetchTransactions(c, a.API)
+		return
+	})
+	check(err)
+	// the accounts that succeeded and their transactions
+	succeeded := make([]account, len(ok))
+	fetched := make([]*n26.Transactions, len(ok))
+	for j, i := range ok {
+		succeeded[j], fetched[j] = accounts[i], results[i]
+	}
+	merged := []accountTransaction{}
+	for i, transactions := range fetched {
+		for _, t := range *transactions {
+			merged = append(merged, accountTransaction{succeeded[i].name, t})
+		}
+	}
+	// newest first, as N26 lists them
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].VisibleTS.After(merged[j].VisibleTS.Time) })
+	if err := renderTable(c, merged, merged, accountTransactionColumns(rates)); err != nil {
+		return err
+	}
+	if tableOutput(c) {
+		fmt.Println()
+		return NewTableWriter().WriteData(accountTotals(columnSelectionFromFlags(c), succeeded, fetched))
+	}
+	return nil
+}
+
+// The number of transactions, income and expenses per account and of all accounts
+func accountTotals(s columnSelection, accounts []account, fetched []*n26.Transactions) ([]string, [][]string) {
+	rows := [][]string{}
+	var count int
+	var income, expenses float64
+	row := func(name string, count int, income, expenses float64) []string {
+		return []string{name, fmt.Sprint(count), s.cell(money{income, "EUR"}), s.cell(money{expenses, "EUR"}),
+			s.cell(money{income + expenses, "EUR"})}
+	}
+	for i, transactions := range fetched {
+		var in, out float64
+		for _, t := range *transactions {
+			if t.Amount > 0 {
+				in += t.Amount
+			} else {
+				out += t.Amount
+			}
+		}
+		rows = append(rows, row(accounts[i].name, len(*transactions), in, out))
+		count, income, expenses = count+len(*transactions), income+in, expenses+out
+	}
+	rows = append(rows, row("Total", count, income, expenses))
+	return []string{"Account", "Transactions", "Income", "Expenses", "Total"}, rows
+}
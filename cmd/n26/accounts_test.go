@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEachAccount(t *testing.T) {
+	accounts := []account{{name: "a"}, {name: "b"}, {name: "c"}}
+	cases := []struct {
+		name    string
+		failing map[string]bool
+		ok      []int
+		err     bool
+	}{
+		{"all succeed", nil, []int{0, 1, 2}, false},
+		{"one fails", map[string]bool{"b": true}, []int{0, 2}, false},
+		{"all fail", map[string]bool{"a": true, "b": true, "c": true}, nil, true},
+	}
+	for _, c := range cases {
+		ok, err := eachAccount(accounts, func(i int, a account) error {
+			if c.failing[a.name] {
+				return errors.New("failed")
+			}
+			return nil
+		})
+		if (err != nil) != c.err || !reflect.DeepEqual(ok, c.ok) {
+			t.Errorf("%s: got %v, %v, want %v, error %v", c.name, ok, err, c.ok, c.err)
+		}
+	}
+}
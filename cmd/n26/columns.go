@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+func columnFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{Name: "columns", Usage: "columns of table, CSV and TSV output, e.g. time,merchant,amount,category. " +
+			"Besides the columns of the table, any field can be shown by its JSON name, e.g. referenceText, mcc or cardId"},
+		cli.StringFlag{Name: "sort", Usage: "column to sort by, descending if prefixed with '-', e.g. -amount"},
+		cli.BoolFlag{Name: "no-header", Usage: "leave out the header of table, CSV and TSV output"},
+	}
+}
+
+// A column of a table of items
+type column struct {
+	name, header string
+	value        func(item reflect.Value) interface{}
+}
+
+// The columns of the items of a model, those shown by default first. Any
+// field of the items can be shown as well, by its JSON or Go name, with dots
+// for nested fields, e.g. referenceText or balance.availableBalance.
+type columnRegistry struct {
+	columns  []column
+	defaults int
+}
+
+// The columns and order chosen by the column flags
+type columnSelection struct {
+	columns    []string
+	sort       string
+	descending bool
+	noHeader   bool
+	// formatting of table output
+	locale *locale
+	color  bool
+}
+
+func columnSelectionFromFlags(c *cli.Context) columnSelection {
+	s := columnSelection{noHeader: c.GlobalBool("no-header")}
+	for _, name := range strings.Split(c.GlobalString("columns"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			s.columns = append(s.columns, name)
+		}
+	}
+	if tableOutput(c) {
+		s.locale, s.color = displayLocale, colorOutput()
+	}
+	s.sort = strings.TrimSpace(c.GlobalString("sort"))
+	if strings.HasPrefix(s.sort, "-") {
+		s.sort, s.descending = s.sort[1:], true
+	}
+	return s
+}
+
+// The header of the output, none if left out by --no-header
+func (s columnSelection) header(header []string) []string {
+	if s.noHeader {
+		return nil
+	}
+	return header
+}
+
+// The text of a cell, amounts and times formatted for the locale and
+// amounts coloured if selected
+func (s columnSelection) cell(value interface{}) string {
+	switch v := value.(type) {
+	case money:
+		text := formatCell(v)
+		if s.locale != nil {
+			text = s.locale.money(v)
+		}
+		if s.color {
+			text = colorAmount(v.Amount, text)
+		}
+		return text
+	case n26.TimeStamp:
+		if s.locale != nil {
+			return s.locale.time(v.Time)
+		}
+	case time.Time:
+		if s.locale != nil {
+			return s.locale.time(v)
+		}
+	}
+	return formatCell(value)
+}
+
+// The value of a field of the item, by Go field names separated by dots
+func field(path string) func(reflect.Value) interface{} {
+	return func(item reflect.Value) interface{} {
+		for _, name := range strings.Split(path, ".") {
+			for item.Kind() == reflect.Ptr {
+				if item.IsNil() {
+					return nil
+				}
+				item = item.Elem()
+			}
+			item = item.FieldByName(name)
+		}
+		return item.Interface()
+	}
+}
+
+func (r columnRegistry) column(name string, itemType reflect.Type) (column, error) {
+	for _, c := range r.columns {
+		if strings.EqualFold(name, c.name) {
+			return c, nil
+		}
+	}
+	if c, ok := fieldColumn(itemType, name); ok {
+		return c, nil
+	}
+	names := []string{}
+	for _, c := range r.columns {
+		names = append(names, c.name)
+	}
+	return column{}, fmt.Errorf("unknown column %q, expected one of %s or a field name", name, strings.Join(names, ", "))
+}
+
+// The amount of a field of the item in the currency
+func amountField(path, currency string) func(reflect.Value) interface{} {
+	return func(item reflect.Value) interface{} {
+		amount, _ := field(path)(item).(float64)
+		return money{amount, currency}
+	}
+}
+
+// A column of the field of the type with the JSON or Go name, dots separating nested fields
+func fieldColumn(t reflect.Type, path string) (column, bool) {
+	names := []string{}
+	for _, part := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return column{}, false
+		}
+		f, found := structField(t, part)
+		if !found {
+			return column{}, false
+		}
+		names, t = append(names, f.Name), f.Type
+	}
+	return column{path, strings.Join(names, " "), field(strings.Join(names, "."))}, true
+}
+
+// The exported field of the struct type with the JSON or Go name, including
+// the fields promoted from embedded structs
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.PkgPath == "" && (strings.EqualFold(name, jsonName) || strings.EqualFold(name, f.Name)) {
+			return f, true
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if promoted, ok := structField(f.Type, name); ok {
+				return promoted, true
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// The header and rows of the list of items with the selected columns, sorted
+// if a sort column is selected.
+func (r columnRegistry) table(s columnSelection, items interface{}) (header []string, rows [][]string, err error) {
+	list := reflect.Indirect(reflect.ValueOf(items))
+	itemType := list.Type().Elem()
+	columns := []column{}
+	if len(s.columns) == 0 {
+		columns = r.columns[:r.defaults]
+	}
+	for _, name := range s.columns {
+		c, err := r.column(name, itemType)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, c)
+	}
+	values := make([]reflect.Value, list.Len())
+	for i := range values {
+		values[i] = list.Index(i)
+	}
+	if s.sort != "" {
+		by, err := r.column(s.sort, itemType)
+		if err != nil {
+			return nil, nil, err
+		}
+		sort.SliceStable(values, func(i, j int) bool {
+			order := compareCells(by.value(values[i]), by.value(values[j]))
+			if s.descending {
+				return order > 0
+			}
+			return order < 0
+		})
+	}
+	for _, c := range columns {
+		header = append(header, c.header)
+	}
+	rows = [][]string{}
+	for _, value := range values {
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			row[i] = s.cell(c.value(value))
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+// The result of a command showing the items of the data
+func tableResult(c *cli.Context, data, items interface{}, r columnRegistry) (result, error) {
+	header, rows, err := r.table(columnSelectionFromFlags(c), items)
+	return result{data, header, rows}, err
+}
+
+// The result of a command showing a table of strings, such as a report.
+// Columns are chosen by their header.
+func rowsResult(c *cli.Context, header []string, rows [][]string) (result, error) {
+	r := columnRegistry{defaults: len(header)}
+	for i, name := range header {
+		i := i
+		r.columns = append(r.columns, column{strings.Replace(strings.ToLower(name), " ", "-", -1), name,
+			func(row reflect.Value) interface{} { return row.Index(i).String() }})
+	}
+	header, rows, err := r.table(columnSelectionFromFlags(c), rows)
+	return result{nil, header, rows}, err
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case money:
+		return strconv.FormatFloat(v.Amount, 'f', -1, 64)
+	case n26.TimeStamp:
+		if v.IsZero() {
+			return ""
+		}
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// A number of a numeric cell, such as 12.5 or 1.50%
+func cellNumber(value interface{}) (float64, bool) {
+	if m, ok := value.(money); ok {
+		return m.Amount, true
+	}
+	if s, ok := value.(string); ok {
+		number, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		return number, err == nil
+	}
+	number, err := toFloat(value)
+	return number, err == nil
+}
+
+// Compare cells by number, time or text
+func compareCells(a, b interface{}) int {
+	if x, ok := cellNumber(a); ok {
+		if y, ok := cellNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := a.(n26.TimeStamp); ok {
+		a = x.Time
+	}
+	if y, ok := b.(n26.TimeStamp); ok {
+		b = y.Time
+	}
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			switch {
+			case x.Before(y):
+				return -1
+			case x.After(y):
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := a.(bool); ok {
+		if y, ok := b.(bool); ok && x != y {
+			if y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(strings.ToLower(formatCell(a)), strings.ToLower(formatCell(b)))
+}
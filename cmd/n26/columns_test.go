@@ -0,0 +1,131 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testItem struct {
+	Name    string       `json:"name"`
+	Amount  float64      `json:"amount"`
+	Address *testAddress `json:"address,omitempty"`
+	secret  string
+}
+
+var testColumns = columnRegistry{columns: []column{
+	{"name", "Name", field("Name")},
+	{"amount", "Amount", amountField("Amount", "EUR")},
+	{"city", "City", field("Address.City")},
+}, defaults: 2}
+
+func TestColumnTable(t *testing.T) {
+	items := []testItem{
+		{"Pizza", -12.5, &testAddress{"Berlin"}, "a"},
+		{"salary", 1500, nil, "b"},
+		{"Rent", -800, &testAddress{"Hamburg"}, "c"},
+	}
+	cases := []struct {
+		name      string
+		selection columnSelection
+		header    []string
+		rows      [][]string
+		err       string
+	}{
+		{"defaults", columnSelection{}, []string{"Name", "Amount"},
+			[][]string{{"Pizza", "-12.5"}, {"salary", "1500"}, {"Rent", "-800"}}, ""},
+		{"registered and case insensitive", columnSelection{columns: []string{"CITY", "name"}}, []string{"City", "Name"},
+			[][]string{{"Berlin", "Pizza"}, {"", "salary"}, {"Hamburg", "Rent"}}, ""},
+		{"JSON and nested names", columnSelection{columns: []string{"address.city", "Amount"}}, []string{"Address City", "Amount"},
+			[][]string{{"Berlin", "-12.5"}, {"", "1500"}, {"Hamburg", "-800"}}, ""},
+		{"sorted descending", columnSelection{columns: []string{"name"}, sort: "amount", descending: true}, []string{"Name"},
+			[][]string{{"salary"}, {"Pizza"}, {"Rent"}}, ""},
+		{"sorted by text", columnSelection{columns: []string{"name"}, sort: "name"}, []string{"Name"},
+			[][]string{{"Pizza"}, {"Rent"}, {"salary"}}, ""},
+		{"sorted by nested field", columnSelection{columns: []string{"name"}, sort: "address.city"}, []string{"Name"},
+			[][]string{{"salary"}, {"Pizza"}, {"Rent"}}, ""},
+		{"no header", columnSelection{columns: []string{"name"}, noHeader: true}, nil,
+			[][]string{{"Pizza"}, {"salary"}, {"Rent"}}, ""},
+		{"unknown column", columnSelection{columns: []string{"merchant"}}, nil, nil,
+			`unknown column "merchant", expected one of name, amount, city or a field name`},
+		{"unexported field", columnSelection{columns: []string{"secret"}}, nil, nil, `unknown column "secret"`},
+		{"field of a non-struct", columnSelection{columns: []string{"name.first"}}, nil, nil, `unknown column "name.first"`},
+		{"unknown sort column", columnSelection{sort: "merchant"}, nil, nil, `unknown column "merchant"`},
+	}
+	for _, c := range cases {
+		header, rows, err := testColumns.table(c.selection, items)
+		if c.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+				t.Errorf("%s: got error %v, want %s", c.name, err, c.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(c.selection.header(header), c.header) || !reflect.DeepEqual(rows, c.rows) {
+			t.Errorf("%s: got %q, %q, %v, want %q, %q", c.name, header, rows, err, c.header, c.rows)
+		}
+	}
+}
+
+func TestStructField(t *testing.T) {
+	cases := []struct {
+		name  string
+		field string
+		found bool
+	}{
+		{"account", "Account", true},
+		{"iban", "IBAN", true},
+		{"availableBalance", "AvailableBalance", true},
+		{"USABLEBALANCE", "UsableBalance", true},
+		{"totalBalance", "TotalBalance", true},
+		{"balance", "Balance", true},
+		{"overdraft", "", false},
+	}
+	for _, c := range cases {
+		f, found := structField(reflect.TypeOf(accountBalance{}), c.name)
+		if found != c.found || f.Name != c.field {
+			t.Errorf("%s: got %q, %v, want %q, %v", c.name, f.Name, found, c.field, c.found)
+		}
+	}
+	c, ok := fieldColumn(reflect.TypeOf(&accountBalance{}), "balance.iban")
+	if !ok || c.header != "Balance IBAN" {
+		t.Errorf("balance.iban: got %+v, %v", c, ok)
+	}
+	item := reflect.ValueOf(accountBalance{Balance: n26.Balance{IBAN: "DE1"}})
+	if value := c.value(item); value != "DE1" {
+		t.Errorf("balance.iban: got %v, want DE1", value)
+	}
+}
+
+func TestCompareCells(t *testing.T) {
+	day := time.Date(2018, 3, 17, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		a, b interface{}
+		want int
+	}{
+		{money{-12.5, "EUR"}, money{1500, "EUR"}, -1},
+		{money{9, "EUR"}, money{10, "EUR"}, -1},
+		{2.5, 2.5, 0},
+		{"10", "9", 1},
+		{"1.50%", "10%", -1},
+		{n26.TimeStamp{Time: day}, n26.TimeStamp{Time: day.Add(time.Hour)}, -1},
+		{day.Add(time.Hour), day, 1},
+		{true, false, 1},
+		{false, true, -1},
+		{"rewe", "REWE", 0},
+		{"Lidl", "rewe", -1},
+		{"9 shops", "10 shops", 1},
+		{nil, "a", -1},
+	}
+	for _, c := range cases {
+		if got := compareCells(c.a, c.b); got != c.want {
+			t.Errorf("%v, %v: got %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A named account with its login and display settings
+type profile struct {
+	name        string
+	username    string
+	deviceToken string
+	output      string
+	locale      string
+	// shell commands printing the credentials, e.g. pass show n26
+	usernameCommand    string
+	passwordCommand    string
+	deviceTokenCommand string
+	// file holding the password, readable by the user only
+	passwordFile string
+	// file to keep the access token in between runs
+	tokenStore string
+}
+
+// The keys of a profile in the config file
+var profileKeys = []struct {
+	key   string
+	field func(*profile) *string
+}{
+	{"username", func(p *profile) *string { return &p.username }},
+	{"device_token", func(p *profile) *string { return &p.deviceToken }},
+	{"output", func(p *profile) *string { return &p.output }},
+	{"locale", func(p *profile) *string { return &p.locale }},
+	{"token_store", func(p *profile) *string { return &p.tokenStore }},
+	{"username_command", func(p *profile) *string { return &p.usernameCommand }},
+	{"password_command", func(p *profile) *string { return &p.passwordCommand }},
+	{"password_file", func(p *profile) *string { return &p.passwordFile }},
+	{"device_token_command", func(p *profile) *string { return &p.deviceTokenCommand }},
+}
+
+// The config file: named profiles and the one used by default
+type config struct {
+	defaultProfile string
+	profiles       []*profile
+}
+
+func (c *config) profile(name string) *profile {
+	for _, p := range c.profiles {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// The path of the config file, in the XDG config directory
+func configPath() string {
+	if path := os.Getenv("N26_CONFIG"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "n26", "config.toml")
+}
+
+// Expand a leading ~ in a path of the config file to the home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
+
+// Read the config file. A missing file is an empty config.
+func readConfig(path string) (*config, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	c, err := parseConfig(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+var (
+	tomlTable = regexp.MustCompile(`^\[\s*profiles\.("(?:[^"\\]|\\.)*"|'[^']*'|[A-Za-z0-9_-]+)\s*\]$`)
+	tomlKey   = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*=\s*(.*)$`)
+)
+
+// Parse the config file, written in the subset of TOML needed for it: the
+// default_profile key and a [profiles.<name>] table per profile with string
+// values.
+func parseConfig(r io.Reader) (*config, error) {
+	c := &config{}
+	var current *profile
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if match := tomlTable.FindStringSubmatch(text); match != nil {
+			name := match[1]
+			if !tomlBareKey.MatchString(name) {
+				var err error
+				if name, err = tomlString(name); err != nil {
+					return nil, fmt.Errorf("line %d: %v", line, err)
+				}
+			}
+			if c.profile(name) != nil {
+				return nil, fmt.Errorf("line %d: profile %q defined twice", line, name)
+			}
+			current = &profile{name: name}
+			c.profiles = append(c.profiles, current)
+			continue
+		}
+		match := tomlKey.FindStringSubmatch(text)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: expected a [profiles.<name>] table or <key> = \"<value>\"", line)
+		}
+		value, err := tomlString(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if current == nil {
+			if match[1] != "default_profile" {
+				return nil, fmt.Errorf("line %d: unknown key %q, expected default_profile or a profile table", line, match[1])
+			}
+			c.defaultProfile = value
+			continue
+		}
+		if !current.set(match[1], value) {
+			return nil, fmt.Errorf("line %d: unknown key %q in profile %q", line, match[1], current.name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if c.defaultProfile != "" && c.profile(c.defaultProfile) == nil {
+		return nil, fmt.Errorf("the default profile %q is not defined", c.defaultProfile)
+	}
+	return c, nil
+}
+
+func (p *profile) set(key, value string) bool {
+	for _, k := range profileKeys {
+		if k.key == key {
+			*k.field(p) = value
+			return true
+		}
+	}
+	return false
+}
+
+// A TOML basic or literal string, optionally followed by a comment
+func tomlString(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.HasPrefix(value, `'`):
+		end := strings.IndexByte(value[1:], '\'')
+		if end >= 0 && tomlComment(value[end+2:]) {
+			literal := value[1 : end+1]
+			if i := strings.IndexFunc(literal, tomlControl); i >= 0 {
+				return "", fmt.Errorf("invalid control character %U in %s", literal[i], value)
+			}
+			return literal, nil
+		}
+	case strings.HasPrefix(value, `"`):
+		basic, rest, err := tomlBasicString(value[1:])
+		if err != nil {
+			return "", fmt.Errorf("%v in %s", err, value)
+		}
+		if tomlComment(rest) {
+			return basic, nil
+		}
+	}
+	return "", fmt.Errorf("invalid value %s, expected a quoted string", value)
+}
+
+// TOML escapes of basic strings besides \uXXXX and \UXXXXXXXX
+var tomlEscapes = map[byte]byte{'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', '"': '"', '\\': '\\'}
+
+// Decode a TOML basic string following its opening quote, returning the
+// rest of the line after the closing quote
+func tomlBasicString(value string) (string, string, error) {
+	decoded := &strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '"':
+			return decoded.String(), value[i+1:], nil
+		case c == '\\' && i+1 < len(value):
+			i++
+			if escaped, ok := tomlEscapes[value[i]]; ok {
+				decoded.WriteByte(escaped)
+				continue
+			}
+			digits := map[byte]int{'u': 4, 'U': 8}[value[i]]
+			if digits == 0 || i+digits >= len(value) {
+				return "", "", fmt.Errorf("invalid escape \\%c", value[i])
+			}
+			code, err := strconv.ParseUint(value[i+1:i+1+digits], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", "", fmt.Errorf("invalid escape \\%s", value[i:i+1+digits])
+			}
+			decoded.WriteRune(rune(code))
+			i += digits
+		case tomlControl(rune(c)):
+			return "", "", fmt.Errorf("invalid control character %U", c)
+		default:
+			decoded.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// Whether TOML strings must escape the character, all control characters
+// but tab
+func tomlControl(r rune) bool {
+	return (r < 0x20 && r != '\t') || r == 0x7f
+}
+
+// Quote the text as TOML basic string
+func tomlQuote(text string) string {
+	quoted := &strings.Builder{}
+	quoted.WriteByte('"')
+	for _, r := range text {
+		switch {
+		case r == '"' || r == '\\':
+			quoted.WriteString(`\` + string(r))
+		case r == '\b':
+			quoted.WriteString(`\b`)
+		case r == '\t':
+			quoted.WriteString(`\t`)
+		case r == '\n':
+			quoted.WriteString(`\n`)
+		case r == '\f':
+			quoted.WriteString(`\f`)
+		case r == '\r':
+			quoted.WriteString(`\r`)
+		case tomlControl(r):
+			fmt.Fprintf(quoted, `\u%04X`, r)
+		default:
+			quoted.WriteRune(r)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// Whether the rest of a line is empty or a comment
+func tomlComment(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Write the config file, profiles sorted by name
+func writeConfig(w io.Writer, c *config) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "# n26 profiles, see 'n26 profile --help'")
+	if c.defaultProfile != "" {
+		fmt.Fprintf(out, "default_profile = %s\n", tomlQuote(c.defaultProfile))
+	}
+	profiles := append([]*profile{}, c.profiles...)
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].name < profiles[j].name })
+	for _, p := range profiles {
+		name := p.name
+		if !tomlBareKey.MatchString(name) {
+			name = tomlQuote(name)
+		}
+		fmt.Fprintf(out, "\n[profiles.%s]\n", name)
+		for _, k := range profileKeys {
+			if value := *k.field(p); value != "" {
+				fmt.Fprintf(out, "%s = %s\n", k.key, tomlQuote(value))
+			}
+		}
+	}
+	return out.Flush()
+}
+
+// Save the config file, readable by the user only as it may hold credentials
+func saveConfig(path string, c *config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	// an existing file keeps its mode otherwise
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writeConfig(file, c); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
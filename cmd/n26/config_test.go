@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/guitmz/n26"
+)
+
+func TestTomlString(t *testing.T) {
+	cases := []struct {
+		value string
+		want  string
+		err   bool
+	}{
+		{`"a@b.c"`, "a@b.c", false},
+		{`"a@b.c" # work`, "a@b.c", false},
+		{`"pass show \"n26\""`, `pass show "n26"`, false},
+		{`"tab\there \\ caf\u00e9"`, "tab\there \\ café", false},
+		{`'C:\tokens\n26.json'`, `C:\tokens\n26.json`, false},
+		{`'it''s'`, "", true},
+		{`"#not a comment"`, "#not a comment", false},
+		{`"unterminated`, "", true},
+		{`"a" b`, "", true},
+		{`bare`, "", true},
+		{``, "", true},
+		// only the escapes of TOML
+		{`"\b\f\r\n\U0001F600 \u00E9"`, "\b\f\r\n😀 é", false},
+		{`"\x41"`, "", true},
+		{`"\101"`, "", true},
+		{`"\a"`, "", true},
+		{`"\'"`, "", true},
+		{`"\u00e"`, "", true},
+		{`"\uD800"`, "", true},
+		{`"\U00110000"`, "", true},
+		{`"\u+0e9"`, "", true},
+		{"\"a\x01b\"", "", true},
+		{"'a\x7fb'", "", true},
+		{"\"a\tb\"", "a\tb", false},
+		{`"a\"`, "", true},
+	}
+	for _, c := range cases {
+		got, err := tomlString(c.value)
+		if (err != nil) != c.err || got != c.want {
+			t.Errorf("%s: got %q, %v, want %q, error %v", c.value, got, err, c.want, c.err)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		want   *config
+		err    string
+	}{
+		{"empty", "", &config{}, ""},
+		{
+			"profiles",
+			`# n26 profiles
+default_profile = "work"
+
+[profiles.work]
+username = "a@b.c" # the work account
+device_token = 'f0e1d2c3-0000-4000-8000-000000000000'
+
+[ profiles."my bank" ]
+  password_command = "pass show \"n26\""
+`,
+			&config{"work", []*profile{
+				{name: "work", username: "a@b.c", deviceToken: "f0e1d2c3-0000-4000-8000-000000000000"},
+				{name: "my bank", passwordCommand: `pass show "n26"`},
+			}},
+			"",
+		},
+		{"duplicate profile", "[profiles.a]\n[profiles.\"a\"]\n", nil, `line 2: profile "a" defined twice`},
+		{"unknown key", "[profiles.a]\npassword = \"secret\"\n", nil, `line 2: unknown key "password" in profile "a"`},
+		{"key outside profile", "username = \"a@b.c\"\n", nil, `line 1: unknown key "username"`},
+		{"unquoted value", "[profiles.a]\nusername = a@b.c\n", nil, "line 2: invalid value a@b.c"},
+		{"other table", "[accounts]\n", nil, "line 1: expected a [profiles.<name>] table"},
+		{"undefined default", "default_profile = \"b\"\n[profiles.a]\n", nil, `the default profile "b" is not defined`},
+		{"literal profile name", "[profiles.'my bank']\n", &config{"", []*profile{{name: "my bank"}}}, ""},
+		{"invalid profile name", "[profiles.\"a\\x41\"]\n", nil, `line 1: invalid escape \x`},
+	}
+	for _, c := range cases {
+		got, err := parseConfig(strings.NewReader(c.config))
+		if c.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+				t.Errorf("%s: got error %v, want %s", c.name, err, c.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, %v, want %+v", c.name, got, err, c.want)
+		}
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	c := &config{"my bank", []*profile{
+		{name: "work", username: "a@b.c", output: "json", locale: "de-DE", tokenStore: "~/.cache/n26/work.json"},
+		{name: "my bank", passwordCommand: `pass show "n26" | head -1`, passwordFile: `C:\n26\password`,
+			usernameCommand: "echo 'café'\t#", deviceTokenCommand: "cat token\x1b\r\n", deviceToken: "d"},
+	}}
+	var out bytes.Buffer
+	if err := writeConfig(&out, c); err != nil {
+		t.Fatal(err)
+	}
+	want := `# n26 profiles, see 'n26 profile --help'
+default_profile = "my bank"
+
+[profiles."my bank"]
+device_token = "d"
+username_command = "echo 'café'\t#"
+password_command = "pass show \"n26\" | head -1"
+password_file = "C:\\n26\\password"
+device_token_command = "cat token\u001B\r\n"
+
+[profiles.work]
+username = "a@b.c"
+output = "json"
+locale = "de-DE"
+token_store = "~/.cache/n26/work.json"
+`
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+	parsed, err := parseConfig(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// written sorted by name
+	c.profiles[0], c.profiles[1] = c.profiles[1], c.profiles[0]
+	if !reflect.DeepEqual(parsed, c) {
+		t.Errorf("round trip: got %+v, want %+v", parsed, c)
+	}
+}
+
+func TestProfileRows(t *testing.T) {
+	c := &config{"work", []*profile{
+		{name: "work", username: "a@b.c", deviceToken: "f0e1d2c3-0000-4000-8000-000000000000", output: "json"},
+		{name: "home", deviceTokenCommand: "pass show n26-device"},
+		{name: "new"},
+	}}
+	want := [][]string{
+		{"work", "yes", "a@b.c", "set", "json", "", ""},
+		{"home", "", "", "command", "", "", ""},
+		{"new", "", "", "", "", "", ""},
+	}
+	if got := profileRows(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSaveRestrictsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no permission bits on Windows")
+	}
+	dir := t.TempDir()
+	saves := map[string]func(path string) error{
+		"config.toml": func(path string) error { return saveConfig(path, &config{}) },
+		"token.json":  func(path string) error { return saveToken(path, &n26.Token{AccessToken: "a"}) },
+	}
+	for name, save := range saves {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := save(path); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("%s: got mode %04o, want 0600", name, perm)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+func passwordFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{Name: "password-stdin", Usage: "read the password from the first line of stdin, " +
+			"e.g. pass show n26 | n26 --password-stdin balance"},
+		cli.IntFlag{Name: "password-fd", Usage: "read the password from the first line of the file descriptor `FD`, " +
+			"e.g. n26 --password-fd 3 balance 3< <(pass show n26)"},
+		cli.StringFlag{Name: "password-file", Usage: "read the password from the first line of `FILE`, " +
+			"which must not be readable by group or others"},
+	}
+}
+
+// Reads the password given by --password-stdin, --password-fd or --password-file, nil without them
+var passwordSource func() (string, error)
+
+// Set the password source of the password flags
+func parsePasswordFlags(c *cli.Context) error {
+	passwordSource = nil
+	given := 0
+	if c.GlobalBool("password-stdin") {
+		given++
+		passwordSource = func() (string, error) { return readPassword(os.Stdin, "stdin") }
+	}
+	if fd := c.GlobalInt("password-fd"); c.GlobalIsSet("password-fd") {
+		given++
+		if fd < 0 {
+			return fmt.Errorf("invalid file descriptor %d", fd)
+		}
+		passwordSource = func() (string, error) {
+			file := os.NewFile(uintptr(fd), fmt.Sprintf("file descriptor %d", fd))
+			if file == nil {
+				return "", fmt.Errorf("invalid file descriptor %d", fd)
+			}
+			defer file.Close()
+			return readPassword(file, file.Name())
+		}
+	}
+	if path := c.GlobalString("password-file"); path != "" {
+		given++
+		passwordSource = func() (string, error) { return readPasswordFile(path) }
+	}
+	if given > 1 {
+		return errors.New("only one of --password-stdin, --password-fd and --password-file can be given")
+	}
+	return nil
+}
+
+// Read the password of the password options now and keep it as password
+// source, for the shell to log in again without reading stdin or the file
+// again
+func cachePassword() error {
+	if passwordSource == nil {
+		return nil
+	}
+	password, err := passwordSource()
+	if err != nil {
+		return err
+	}
+	passwordSource = func() (string, error) { return password, nil }
+	return nil
+}
+
+// A credential from the environment variable, the output of the command or
+// the value of the profile, empty if none is set
+func credential(env, command, value string) (string, error) {
+	if v := os.Getenv(env); v != "" {
+		return v, nil
+	}
+	if command != "" {
+		return runCredentialCommand(command)
+	}
+	return value, nil
+}
+
+// The first line printed by the shell command, such as pass show n26.
+// Stdin and stderr stay connected for the passphrase prompts of password managers.
+func runCredentialCommand(command string) (string, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.Command(shell, flag, command)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", command, err)
+	}
+	line := firstLine(string(out))
+	if line == "" {
+		return "", fmt.Errorf("%s: no output", command)
+	}
+	return line, nil
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSuffix(s, "\r")
+}
+
+// The first line of the reader
+func readPassword(r io.Reader, name string) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("reading the password from %s: %v", name, err)
+	}
+	password := firstLine(line)
+	if password == "" {
+		return "", fmt.Errorf("no password in %s", name)
+	}
+	return password, nil
+}
+
+// The first line of the file, refusing files readable by group or others
+func readPasswordFile(path string) (string, error) {
+	path = expandHome(path)
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	// Windows has no permission bits to check
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm&0077 != 0 {
+		return "", fmt.Errorf("%s is accessible by group or others (mode %04o), restrict it with chmod 600 %s", path, perm, path)
+	}
+	return readPassword(file, path)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestFirstLine(t *testing.T) {
+	cases := []struct {
+		s, want string
+	}{
+		{"secret", "secret"},
+		{"secret\n", "secret"},
+		{"secret\r\n", "secret"},
+		{"secret\r\nsecond\r\n", "secret"},
+		{"sec ret \n", "sec ret "},
+		{"\nsecond", ""},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := firstLine(c.s); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.s, got, c.want)
+		}
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	cases := []struct {
+		input, want, err string
+	}{
+		{"secret\r\n", "secret", ""},
+		{"secret", "secret", ""},
+		{"secret\nsecond\n", "secret", ""},
+		{"\r\n", "", "no password in stdin"},
+		{"", "", "no password in stdin"},
+	}
+	for _, c := range cases {
+		got, err := readPassword(strings.NewReader(c.input), "stdin")
+		if got != c.want || (err == nil) != (c.err == "") || (err != nil && err.Error() != c.err) {
+			t.Errorf("%q: got %q, %v, want %q, %s", c.input, got, err, c.want, c.err)
+		}
+	}
+}
+
+func TestReadPasswordFile(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+		want    string
+		err     string
+	}{
+		{"private", "secret\r\n", 0600, "secret", ""},
+		{"read only", "secret\n", 0400, "secret", ""},
+		{"group readable", "secret\n", 0640, "", "accessible by group or others (mode 0640)"},
+		{"world readable", "secret\n", 0644, "", "accessible by group or others (mode 0644)"},
+		{"empty", "", 0600, "", "no password in"},
+	}
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name)
+		if err := ioutil.WriteFile(path, []byte(c.content), c.mode); err != nil {
+			t.Fatal(err)
+		}
+		// not subject to the umask
+		if err := os.Chmod(path, c.mode); err != nil {
+			t.Fatal(err)
+		}
+		if runtime.GOOS == "windows" && c.mode&0077 != 0 {
+			continue
+		}
+		got, err := readPasswordFile(path)
+		if got != c.want || (err == nil) != (c.err == "") || (err != nil && !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%s: got %q, %v, want %q, %s", c.name, got, err, c.want, c.err)
+		}
+	}
+	if _, err := readPasswordFile(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("missing: got no error")
+	}
+}
+
+func TestParsePasswordFlags(t *testing.T) {
+	cases := []struct {
+		args   []string
+		source bool
+		err    string
+	}{
+		{nil, false, ""},
+		{[]string{"--password-stdin"}, true, ""},
+		{[]string{"--password-fd", "3"}, true, ""},
+		{[]string{"--password-fd", "-1"}, false, "invalid file descriptor -1"},
+		{[]string{"--password-file", "~/.n26"}, true, ""},
+		{[]string{"--password-stdin", "--password-file", "~/.n26"}, false, "only one of"},
+		{[]string{"--password-fd", "0", "--password-file", "~/.n26"}, false, "only one of"},
+		{[]string{"--password-stdin", "--password-fd", "0"}, false, "only one of"},
+	}
+	for _, c := range cases {
+		app := cli.NewApp()
+		app.Flags = passwordFlags()
+		var err error
+		app.Action = func(ctx *cli.Context) error {
+			err = parsePasswordFlags(ctx)
+			return nil
+		}
+		if runErr := app.Run(append([]string{"n26"}, c.args...)); runErr != nil {
+			t.Fatal(runErr)
+		}
+		if c.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+				t.Errorf("%v: got error %v, want %s", c.args, err, c.err)
+			}
+			continue
+		}
+		if err != nil || (passwordSource != nil) != c.source {
+			t.Errorf("%v: got source %v, %v, want source %v", c.args, passwordSource != nil, err, c.source)
+		}
+	}
+	passwordSource = nil
+}
+
+func TestCachePassword(t *testing.T) {
+	defer func() { passwordSource = nil }()
+	reads := 0
+	passwordSource = func() (string, error) { reads++; return "secret", nil }
+	if err := cachePassword(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if password, err := passwordSource(); password != "secret" || err != nil {
+			t.Errorf("got %q, %v", password, err)
+		}
+	}
+	if reads != 1 {
+		t.Errorf("read the source %d times, want once", reads)
+	}
+	passwordSource = func() (string, error) { return "", errors.New("empty password") }
+	if err := cachePassword(); err == nil {
+		t.Error("got no error")
+	}
+	passwordSource = nil
+	if err := cachePassword(); err != nil || passwordSource != nil {
+		t.Errorf("without password options: got %v", err)
+	}
+}
@@ -15,8 +15,10 @@ func NewCsvWriter(target io.Writer) (*csvWriter, error) {
 }
 
 func (w *csvWriter) WriteData(header []string, data [][]string) error {
-	if err := w.Write(header); err != nil {
-		return err
+	if header != nil {
+		if err := w.Write(header); err != nil {
+			return err
+		}
 	}
 	if err := w.WriteAll(data); err != nil {
 		return err
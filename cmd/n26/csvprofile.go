@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+// A CSV layout expected by an importing application
+type csvProfile struct {
+	delimiter  rune
+	decimal    string
+	dateFormat string
+	// amounts in separate outflow and inflow columns instead of one signed column
+	split   bool
+	columns []csvColumn
+}
+
+type csvColumn struct {
+	field, header string
+}
+
+func csvPayee(p csvProfile, t n26.Transaction) string {
+	if t.PartnerName != "" {
+		return t.PartnerName
+	}
+	return t.MerchantName
+}
+
+// The values of the transaction fields CSV columns can show
+var csvFields = map[string]func(csvProfile, n26.Transaction) string{
+	// in the time zone of N26, like the other exports
+	"date": func(p csvProfile, t n26.Transaction) string {
+		return t.VisibleTS.In(n26.Location()).Format(p.dateFormat)
+	},
+	"payee": csvPayee,
+	"memo":  func(p csvProfile, t n26.Transaction) string { return t.ReferenceText },
+	"description": func(p csvProfile, t n26.Transaction) string {
+		if t.ReferenceText != "" {
+			return t.ReferenceText
+		}
+		return csvPayee(p, t)
+	},
+	"iban":     func(p csvProfile, t n26.Transaction) string { return t.PartnerIban },
+	"bic":      func(p csvProfile, t n26.Transaction) string { return t.PartnerBic },
+	"category": func(p csvProfile, t n26.Transaction) string { return t.CategoryName() },
+	"type":     func(p csvProfile, t n26.Transaction) string { return t.Type },
+	"id":       func(p csvProfile, t n26.Transaction) string { return t.ID },
+	"amount":   func(p csvProfile, t n26.Transaction) string { return p.amount(t.Amount) },
+	"inflow": func(p csvProfile, t n26.Transaction) string {
+		if t.Amount > 0 {
+			return p.amount(t.Amount)
+		}
+		return ""
+	},
+	"outflow": func(p csvProfile, t n26.Transaction) string {
+		if t.Amount < 0 {
+			return p.amount(-t.Amount)
+		}
+		return ""
+	},
+	"currency": func(p csvProfile, t n26.Transaction) string { return t.CurrencyCode },
+	"original-amount": func(p csvProfile, t n26.Transaction) string {
+		if t.IsForeignCurrency() {
+			return p.amount(t.OriginalAmount)
+		}
+		return ""
+	},
+	"original-currency": func(p csvProfile, t n26.Transaction) string { return t.OriginalCurrency },
+}
+
+var csvProfiles = map[string]csvProfile{
+	"ynab": {',', ".", "2006-01-02", true, []csvColumn{
+		{"date", "Date"}, {"payee", "Payee"}, {"memo", "Memo"}, {"amount", "Amount"},
+	}},
+	"firefly": {',', ".", "2006-01-02", false, []csvColumn{
+		{"date", "Date"}, {"description", "Description"}, {"amount", "Amount"}, {"currency", "Currency"},
+		{"payee", "Opposing account"}, {"iban", "Opposing IBAN"}, {"category", "Category"}, {"id", "External ID"},
+	}},
+	"actual": {',', ".", "2006-01-02", false, []csvColumn{
+		{"date", "Date"}, {"payee", "Payee"}, {"memo", "Notes"}, {"category", "Category"}, {"amount", "Amount"},
+	}},
+	"generic": {',', ".", "2006-01-02 15:04:05", false, []csvColumn{
+		{"date", "Date"}, {"payee", "Payee"}, {"iban", "IBAN"}, {"bic", "BIC"}, {"memo", "Reference"},
+		{"category", "Category"}, {"type", "Type"}, {"amount", "Amount"}, {"currency", "Currency"},
+		{"original-amount", "Original Amount"}, {"original-currency", "Original Currency"}, {"id", "ID"},
+	}},
+}
+
+func (p csvProfile) amount(amount float64) string {
+	return strings.Replace(strconv.FormatFloat(amount, 'f', 2, 64), ".", p.decimal, 1)
+}
+
+// The columns with the amount expanded into outflow and inflow if split
+func (p csvProfile) expandedColumns(headers map[string]string) []csvColumn {
+	columns := []csvColumn{}
+	for _, column := range p.columns {
+		if column.field == "amount" && p.split {
+			columns = append(columns, csvColumn{"outflow", "Outflow"}, csvColumn{"inflow", "Inflow"})
+		} else {
+			columns = append(columns, column)
+		}
+	}
+	for i, column := range columns {
+		if header, ok := headers[column.field]; ok {
+			columns[i].header = header
+		}
+	}
+	return columns
+}
+
+func csvFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{Name: "csv-profile", Usage: "CSV layout for importing into ynab, firefly (Firefly III), actual (Actual Budget) " +
+			"or generic. Without a profile or other csv option, the table columns are written"},
+		cli.StringFlag{Name: "csv-delimiter", Usage: "column delimiter of CSV output, e.g. ';'"},
+		cli.StringFlag{Name: "csv-decimal", Usage: "decimal separator of amounts in CSV output, e.g. ','"},
+		cli.StringFlag{Name: "csv-date-format", Usage: "date format of CSV output as Go time layout, e.g. 02.01.2006 or 01/02/2006"},
+		cli.StringFlag{Name: "csv-amount", Usage: "'signed' for one amount column or 'split' for outflow and inflow columns"},
+		cli.StringFlag{Name: "csv-headers", Usage: "rename CSV columns by field, e.g. 'date=Datum,amount=Betrag'. Fields are " +
+			"date, payee, memo, description, iban, bic, category, type, id, amount, inflow, outflow, currency, " +
+			"original-amount and original-currency"},
+	}
+}
+
+// The CSV profile given by the csv flags, if any of them is set
+func csvProfileFromFlags(c *cli.Context) (profile csvProfile, headers map[string]string, ok bool, err error) {
+	name := c.String("csv-profile")
+	if name == "" {
+		for _, flag := range []string{"csv-delimiter", "csv-decimal", "csv-date-format", "csv-amount", "csv-headers"} {
+			if c.IsSet(flag) {
+				name = "generic"
+			}
+		}
+		if name == "" {
+			return csvProfile{}, nil, false, nil
+		}
+	}
+	profile, ok = csvProfiles[name]
+	if !ok {
+		return csvProfile{}, nil, false, fmt.Errorf("unknown CSV profile %q, expected ynab, firefly, actual or generic", name)
+	}
+	// the importing application expects the columns of the profile
+	if c.GlobalString("columns") != "" || c.GlobalString("sort") != "" {
+		return csvProfile{}, nil, false, errors.New("--columns and --sort can't be combined with a CSV profile " +
+			"or the csv options, use --csv-headers to rename its columns")
+	}
+	if transactionFormat(c) == "tsv" {
+		if c.String("csv-delimiter") != "" {
+			return csvProfile{}, nil, false, errors.New("tsv output is tab separated, --csv-delimiter can't be used")
+		}
+		profile.delimiter = '\t'
+	}
+	if delimiter := c.String("csv-delimiter"); delimiter != "" {
+		if delimiter == `\t` {
+			delimiter = "\t"
+		}
+		if utf8.RuneCountInString(delimiter) != 1 {
+			return csvProfile{}, nil, false, fmt.Errorf("the CSV delimiter must be a single character, got %q", delimiter)
+		}
+		profile.delimiter, _ = utf8.DecodeRuneInString(delimiter)
+	}
+	if c.String("csv-decimal") != "" {
+		profile.decimal = c.String("csv-decimal")
+	}
+	if c.String("csv-date-format") != "" {
+		profile.dateFormat = c.String("csv-date-format")
+	}
+	switch c.String("csv-amount") {
+	case "":
+	case "signed":
+		profile.split = false
+	case "split":
+		profile.split = true
+	default:
+		return csvProfile{}, nil, false, fmt.Errorf("unknown CSV amount %q, expected signed or split", c.String("csv-amount"))
+	}
+	headers = map[string]string{}
+	if c.String("csv-headers") != "" {
+		for _, rename := range strings.Split(c.String("csv-headers"), ",") {
+			parts := strings.SplitN(rename, "=", 2)
+			field := strings.TrimSpace(parts[0])
+			if _, known := csvFields[field]; !known || len(parts) != 2 {
+				return csvProfile{}, nil, false, fmt.Errorf("invalid CSV header %q, expected <field>=<name>", rename)
+			}
+			headers[field] = strings.TrimSpace(parts[1])
+		}
+	}
+	return profile, headers, true, nil
+}
+
+type csvProfileWriter struct {
+	out      *csv.Writer
+	profile  csvProfile
+	columns  []csvColumn
+	noHeader bool
+}
+
+// NewCsvProfileWriter creates a writer for transactions in the CSV layout of
+// the profile, with the headers of its columns renamed by field or left out.
+func NewCsvProfileWriter(target io.Writer, profile csvProfile, headers map[string]string, noHeader bool) *csvProfileWriter {
+	writer := csv.NewWriter(target)
+	writer.Comma = profile.delimiter
+	return &csvProfileWriter{writer, profile, profile.expandedColumns(headers), noHeader}
+}
+
+func (w *csvProfileWriter) WriteTransactions(transactions *n26.Transactions) error {
+	if !w.noHeader {
+		row := make([]string, len(w.columns))
+		for i, column := range w.columns {
+			row[i] = column.header
+		}
+		if err := w.out.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, transaction := range *transactions {
+		row := make([]string, len(w.columns))
+		for i, column := range w.columns {
+			row[i] = csvFields[column.field](w.profile, transaction)
+		}
+		if err := w.out.Write(row); err != nil {
+			return err
+		}
+	}
+	w.out.Flush()
+	return w.out.Error()
+}
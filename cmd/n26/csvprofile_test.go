@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestCsvProfileWriter(t *testing.T) {
+	cases := []struct {
+		name    string
+		globals []string
+		args    []string
+		want    string
+	}{
+		{"ynab", nil, []string{"--csv-profile", "ynab"}, `Date,Payee,Memo,Outflow,Inflow
+2018-03-17,"Pizza <Place> & ""Bar""",,45.90,
+2018-03-02,Müller & Söhne,"Rent 03/2018` + "\r\n" + `flat 'A:B/C'",800.00,
+2018-03-01,ACME GmbH,Salary: yes # no,,1500.00
+`},
+		{"firefly", nil, []string{"--csv-profile", "firefly"}, `Date,Description,Amount,Currency,Opposing account,Opposing IBAN,Category,External ID
+2018-03-17,"Pizza <Place> & ""Bar""",-45.90,EUR,"Pizza <Place> & ""Bar""",,Food & Groceries,c3
+2018-03-02,"Rent 03/2018` + "\r\n" + `flat 'A:B/C'",-800.00,EUR,Müller & Söhne,DE89370400440532013000,Household & Utilities,b2
+2018-03-01,Salary: yes # no,1500.00,EUR,ACME GmbH,,Income,a1
+`},
+		{"generic with options", nil, []string{"--csv-delimiter", ";", "--csv-decimal", ",", "--csv-headers", "amount=Betrag"},
+			`Date;Payee;IBAN;BIC;Reference;Category;Type;Betrag;Currency;Original Amount;Original Currency;ID
+2018-03-17 17:43:00;"Pizza <Place> & ""Bar""";;;;Food & Groceries;PT;-45,90;EUR;-50,00;USD;c3
+2018-03-02 09:00:00;Müller & Söhne;DE89370400440532013000;COBADEFFXXX;"Rent 03/2018` + "\r\n" + `flat 'A:B/C'";Household & Utilities;DT;-800,00;EUR;;;b2
+2018-03-01 00:00:00;ACME GmbH;;;Salary: yes # no;Income;CT;1500,00;EUR;;;a1
+`},
+		{"actual split with tabs", nil, []string{"--csv-profile", "actual", "--csv-delimiter", `\t`, "--csv-amount", "split",
+			"--csv-date-format", "02.01.2006"}, "Date\tPayee\tNotes\tCategory\tOutflow\tInflow\n" +
+			"17.03.2018\t\"Pizza <Place> & \"\"Bar\"\"\"\t\tFood & Groceries\t45.90\t\n" +
+			"02.03.2018\tMüller & Söhne\t\"Rent 03/2018\r\nflat 'A:B/C'\"\tHousehold & Utilities\t800.00\t\n" +
+			"01.03.2018\tACME GmbH\tSalary: yes # no\tIncome\t\t1500.00\n"},
+		{"ynab without header", []string{"--no-header"}, []string{"--csv-profile", "ynab"}, `2018-03-17,"Pizza <Place> & ""Bar""",,45.90,
+2018-03-02,Müller & Söhne,"Rent 03/2018` + "\r\n" + `flat 'A:B/C'",800.00,
+2018-03-01,ACME GmbH,Salary: yes # no,,1500.00
+`},
+		{"ynab as tsv", []string{"--output", "tsv"}, []string{"--csv-profile", "ynab"}, "Date\tPayee\tMemo\tOutflow\tInflow\n" +
+			"2018-03-17\t\"Pizza <Place> & \"\"Bar\"\"\"\t\t45.90\t\n" +
+			"2018-03-02\tMüller & Söhne\t\"Rent 03/2018\r\nflat 'A:B/C'\"\t800.00\t\n" +
+			"2018-03-01\tACME GmbH\tSalary: yes # no\t\t1500.00\n"},
+	}
+	for _, c := range cases {
+		runWithGlobals(t, c.globals, csvFlags(), c.args, func(ctx *cli.Context) {
+			profile, headers, ok, err := csvProfileFromFlags(ctx)
+			if err != nil || !ok {
+				t.Fatalf("%s: got %v, %v", c.name, ok, err)
+			}
+			transactions := testTransactions()
+			// dates are those of N26, whatever the --timezone decoded them in
+			for i := range transactions {
+				transactions[i].VisibleTS.Time = transactions[i].VisibleTS.UTC()
+			}
+			buffer := &bytes.Buffer{}
+			if err := NewCsvProfileWriter(buffer, profile, headers, ctx.GlobalBool("no-header")).WriteTransactions(&transactions); err != nil {
+				t.Fatal(err)
+			}
+			if buffer.String() != c.want {
+				t.Errorf("%s: got\n%s\nwant\n%s", c.name, buffer.String(), c.want)
+			}
+		})
+	}
+}
+
+func TestCsvProfileFromFlags(t *testing.T) {
+	cases := []struct {
+		globals []string
+		args    []string
+		ok      bool
+		err     string
+	}{
+		{nil, nil, false, ""},
+		{nil, []string{"--csv-decimal", ","}, true, ""},
+		{nil, []string{"--csv-profile", "mint"}, false, "unknown CSV profile"},
+		{nil, []string{"--csv-delimiter", ";;"}, false, "the CSV delimiter must be a single character"},
+		{nil, []string{"--csv-amount", "both"}, false, "unknown CSV amount"},
+		{nil, []string{"--csv-headers", "amount"}, false, "invalid CSV header"},
+		{nil, []string{"--csv-headers", "balance=Saldo"}, false, "invalid CSV header"},
+		{[]string{"--columns", "date,amount"}, []string{"--csv-profile", "ynab"}, false, "--columns and --sort can't be combined"},
+		{[]string{"--sort", "amount"}, []string{"--csv-decimal", ","}, false, "--columns and --sort can't be combined"},
+		{[]string{"--columns", "date,amount"}, nil, false, ""},
+		{nil, []string{"--csv-profile", "ynab", "--csv-delimiter", ";", "tsv"}, false, "tsv output is tab separated"},
+		{[]string{"--no-header"}, []string{"--csv-profile", "ynab", "tsv"}, true, ""},
+	}
+	for _, c := range cases {
+		runWithGlobals(t, c.globals, csvFlags(), c.args, func(ctx *cli.Context) {
+			_, _, ok, err := csvProfileFromFlags(ctx)
+			if ok != c.ok || (err == nil) != (c.err == "") || (err != nil && !strings.HasPrefix(err.Error(), c.err)) {
+				t.Errorf("%v %v: got %v, %v, want %v, %s", c.globals, c.args, ok, err, c.ok, c.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"text/template"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+var downloadCommand = cli.Command{
+	Name: "download",
+	Usage: "download statement PDFs, the given statement IDs, all statements or those of a year. " +
+		"Files already present are skipped",
+	ArgsUsage: "[statement ID...]",
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{Name: "all", Usage: "download all statements"},
+		cli.IntFlag{Name: "year", Usage: "download the statements of the year"},
+	}, statementDownloadFlags()...),
+	Action: func(c *cli.Context) error {
+		if !c.Bool("all") && c.Int("year") == 0 && !c.Args().Present() {
+			return cli.NewExitError("Statement IDs, --all or --year must be given!", 1)
+		}
+		downloader, err := statementDownloaderFromFlags(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		API, err := authentication()
+		check(err)
+		files := []statementFile{}
+		for _, ID := range c.Args() {
+			files = append(files, statementFileOfID(ID))
+		}
+		if c.Bool("all") || c.Int("year") != 0 {
+			statements, err := API.GetStatements()
+			check(err)
+			for _, statement := range *statements {
+				if c.Bool("all") || statement.Year == c.Int("year") {
+					files = append(files, newStatementFile(statement.ID, statement.Year, statement.Month))
+				}
+			}
+		}
+		return downloader.download(API, files)
+	},
+}
+
+func statementDownloadFlags() []cli.Flag {
+	return append(statementFileFlags(),
+		cli.BoolFlag{Name: "force", Usage: "download files already present again"},
+		cli.IntFlag{Name: "parallel", Value: 4, Usage: "download up to N statements at a time"},
+	)
+}
+
+// The flags naming the statement PDF files
+func statementFileFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{Name: "out-dir", Value: ".", Usage: "directory to save the PDF files in"},
+		cli.StringFlag{Name: "template", Value: "{{.ID}}.pdf", Usage: "file name as Go template of the statement " +
+			".ID, .Year and .Month (two digits), e.g. '{{.Year}}-{{.Month}}.pdf'"},
+	}
+}
+
+// The fields of a statement available to file name templates
+type statementFile struct {
+	ID    string
+	Year  int
+	Month string
+}
+
+func newStatementFile(ID string, year, month int) statementFile {
+	return statementFile{ID, year, fmt.Sprintf("%02d", month)}
+}
+
+var statementIDRegex = regexp.MustCompile(`^statement-(\d{4})-(\d{1,2})$`)
+
+// The statement of an ID like statement-2018-03
+func statementFileOfID(ID string) statementFile {
+	if match := statementIDRegex.FindStringSubmatch(ID); match != nil {
+		year, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		return newStatementFile(ID, year, month)
+	}
+	return statementFile{ID: ID}
+}
+
+type statementDownloader struct {
+	outDir   string
+	name     *template.Template
+	force    bool
+	parallel int
+}
+
+func statementDownloaderFromFlags(c *cli.Context) (*statementDownloader, error) {
+	name, err := template.New("file").Option("missingkey=error").Parse(c.String("template"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid file name template: %v", err)
+	}
+	parallel := c.Int("parallel")
+	if parallel < 1 {
+		parallel = 1
+	}
+	return &statementDownloader{c.String("out-dir"), name, c.Bool("force"), parallel}, nil
+}
+
+func (d *statementDownloader) path(file statementFile) (string, error) {
+	name := &bytes.Buffer{}
+	if err := d.name.Execute(name, file); err != nil {
+		return "", err
+	}
+	if name.Len() == 0 {
+		return "", fmt.Errorf("empty file name for statement %s", file.ID)
+	}
+	return filepath.Join(d.outDir, name.String()), nil
+}
+
+// Download the statements, a number of them in parallel, reporting each
+// download. Failed downloads don't stop the others.
+func (d *statementDownloader) download(API *n26.Client, files []statementFile) error {
+	var (
+		wg      sync.WaitGroup
+		mutex   sync.Mutex
+		failed  int
+		pending = make(chan struct{}, d.parallel)
+	)
+	report := func(format string, args ...interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		fmt.Printf(format+"\n", args...)
+	}
+	for _, file := range files {
+		path, err := d.path(file)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if _, err := os.Stat(path); err == nil && !d.force {
+			report("[=] PDF file %s exists, skipped", path)
+			continue
+		}
+		wg.Add(1)
+		go func(ID, path string) {
+			defer wg.Done()
+			pending <- struct{}{}
+			defer func() { <-pending }()
+			if err := saveStatementPDF(API, ID, path); err != nil {
+				mutex.Lock()
+				failed++
+				mutex.Unlock()
+				report("[-] %v", err)
+				return
+			}
+			report("[+] PDF file %s downloaded!", path)
+		}(file.ID, path)
+	}
+	wg.Wait()
+	if failed > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d downloads failed!", failed, len(files)), 1)
+	}
+	return nil
+}
+
+// Save the statement PDF to the file. It is written to a temporary file
+// first, so that an interrupted download doesn't leave a partial file.
+func saveStatementPDF(API *n26.Client, ID, path string) error {
+	pdf, err := API.GetStatementPDF(ID)
+	if err != nil {
+		return err
+	}
+	defer pdf.Close()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(path), ".statement-*.pdf")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := io.Copy(temp, pdf); err != nil {
+		temp.Close()
+		return fmt.Errorf("downloading statement %s: %v", ID, err)
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
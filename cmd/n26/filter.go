@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+func filterFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{Name: "merchant", Usage: "only transactions whose merchant name contains this text. " +
+			"Separate alternatives with commas, prefix with ! to exclude. E.g. 'rewe,lidl' or '!amazon'"},
+		cli.StringFlag{Name: "partner", Usage: "only transactions whose partner name or IBAN contains this text. Same syntax as 'merchant'"},
+		cli.StringFlag{Name: "category", Usage: "only transactions whose category contains this text, e.g. 'groceries'. Same syntax as 'merchant'"},
+		cli.StringFlag{Name: "type", Usage: "only transactions of this N26 type, e.g. 'PT' (card payment), 'CT' (incoming transfer), " +
+			"'DT' (outgoing transfer). Same syntax as 'merchant'"},
+		cli.StringFlag{Name: "card", Usage: "only transactions made with this card ID. Same syntax as 'merchant'"},
+		cli.StringFlag{Name: "text", Usage: "only transactions whose reference text matches this regular expression"},
+		cli.StringFlag{Name: "min", Usage: "only transactions with an absolute amount of at least this value"},
+		cli.StringFlag{Name: "max", Usage: "only transactions with an absolute amount of at most this value"},
+		cli.BoolFlag{Name: "pending", Usage: "only transactions that are not booked yet"},
+		cli.BoolFlag{Name: "any", Usage: "select transactions matching any of the given filters instead of all of them"},
+	}
+}
+
+// Build the filter given by the filter flags. Filters are evaluated on the
+// client, after the 'limit' was applied by N26. If possible, a text for the
+// server side search is returned as well to narrow down the result early.
+func transactionFilter(c *cli.Context) (filter n26.TransactionFilter, search string, err error) {
+	filters := []n26.TransactionFilter{}
+	textFilters := map[string]func(string) n26.TransactionFilter{
+		"merchant": n26.ByMerchant,
+		"partner":  n26.ByPartner,
+		"category": n26.ByCategory,
+		"type":     n26.ByType,
+		"card":     n26.ByCard,
+	}
+	for _, name := range []string{"merchant", "partner", "category", "type", "card"} {
+		if value := c.String(name); value != "" {
+			filters = append(filters, alternatives(value, textFilters[name]))
+		}
+	}
+	if c.String("text") != "" {
+		expression, err := regexp.Compile(c.String("text"))
+		if err != nil {
+			return nil, "", err
+		}
+		filters = append(filters, n26.ByReferenceText(expression))
+	}
+	if c.IsSet("min") || c.IsSet("max") {
+		min, max := 0.0, math.Inf(1)
+		if c.IsSet("min") {
+			if min, err = strconv.ParseFloat(c.String("min"), 64); err != nil {
+				return nil, "", err
+			}
+		}
+		if c.IsSet("max") {
+			if max, err = strconv.ParseFloat(c.String("max"), 64); err != nil {
+				return nil, "", err
+			}
+		}
+		filters = append(filters, n26.ByAmount(min, max))
+	}
+	if c.Bool("pending") {
+		filters = append(filters, n26.Pending)
+	}
+	if len(filters) == 0 {
+		return nil, "", nil
+	}
+	if c.Bool("any") {
+		return n26.Any(filters...), "", nil
+	}
+	// A single merchant name is a safe server side search. The partner isn't,
+	// as N26 searches partner names but not the IBANs the partner filter
+	// matches as well.
+	if value := c.String("merchant"); value != "" && !strings.ContainsAny(value, ",!") {
+		search = value
+	}
+	return n26.All(filters...), search, nil
+}
+
+// Parse a comma separated list of values into a filter that matches any of
+// them. Values prefixed with ! exclude matching transactions instead.
+func alternatives(value string, filterFor func(string) n26.TransactionFilter) n26.TransactionFilter {
+	include := []n26.TransactionFilter{}
+	exclude := []n26.TransactionFilter{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		switch {
+		case v == "" || v == "!":
+			continue
+		case strings.HasPrefix(v, "!"):
+			exclude = append(exclude, n26.Not(filterFor(v[1:])))
+		default:
+			include = append(include, filterFor(v))
+		}
+	}
+	return n26.All(append(exclude, n26.Any(include...))...)
+}
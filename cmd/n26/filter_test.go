@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestTransactionFilterSearch(t *testing.T) {
+	cases := []struct {
+		args   []string
+		filter bool
+		search string
+	}{
+		{nil, false, ""},
+		{[]string{"--merchant", "rewe"}, true, "rewe"},
+		{[]string{"--merchant", "rewe,edeka"}, true, ""},
+		{[]string{"--merchant", "!rewe"}, true, ""},
+		{[]string{"--partner", "DE89370400440532013000"}, true, ""},
+		{[]string{"--partner", "ACME"}, true, ""},
+		{[]string{"--merchant", "rewe", "--partner", "DE89"}, true, "rewe"},
+		{[]string{"--merchant", "rewe", "--any", "--pending"}, true, ""},
+	}
+	for _, c := range cases {
+		runWithFlags(t, transactionFlags(), c.args, func(ctx *cli.Context) {
+			filter, search, err := transactionFilter(ctx)
+			if err != nil || (filter != nil) != c.filter || search != c.search {
+				t.Errorf("%v: got filter %v, search %q, %v, want filter %v, search %q",
+					c.args, filter != nil, search, err, c.filter, c.search)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+var formatFlag = cli.StringFlag{Name: "format", Usage: "Go template to print the data of a command with, " +
+	"e.g. '{{.AvailableBalance}} EUR' or '{{range .}}{{date .VisibleTS}} {{money .Amount}}\\n{{end}}'. " +
+	"Helpers: money, date, pad, padLeft, upper, lower, trim, join and json"}
+
+// The template of the --format flag, if set
+var formatTemplate *template.Template
+
+// The functions available to --format templates
+var formatFuncs = template.FuncMap{
+	// amount with the decimals of the currency, followed by it if given, or
+	// formatted for the --locale
+	"money": func(amount interface{}, currency ...string) (string, error) {
+		value, err := toFloat(amount)
+		if err != nil {
+			return "", err
+		}
+		m := money{Amount: value}
+		if len(currency) > 0 {
+			m.Currency = currency[0]
+		}
+		if displayLocale != nil {
+			return displayLocale.money(m), nil
+		}
+		formatted := strconv.FormatFloat(value, 'f', minorUnits(m.Currency), 64)
+		if m.Currency != "" {
+			formatted += " " + m.Currency
+		}
+		return formatted, nil
+	},
+	// time stamp in the display time zone, formatted by the Go layout if given
+	// or else for the --locale
+	"date": func(value interface{}, layout ...string) (string, error) {
+		var t time.Time
+		switch v := value.(type) {
+		case time.Time:
+			t = v
+		case n26.TimeStamp:
+			t = v.Time
+		case *n26.TimeStamp:
+			t = v.Time
+		case int64:
+			// milliseconds, as the API returns them
+			t = n26.TimeStampFromMillis(v).Time
+		default:
+			return "", fmt.Errorf("date of %T", value)
+		}
+		if t.IsZero() {
+			return "", nil
+		}
+		format := "2006-01-02 15:04"
+		if displayLocale != nil {
+			format = displayLocale.dateTime
+		}
+		if len(layout) > 0 {
+			format = layout[0]
+		}
+		return t.In(n26.DisplayLocation).Format(format), nil
+	},
+	"pad": func(width int, value interface{}) string {
+		return fmt.Sprintf("%-*v", width, value)
+	},
+	"padLeft": func(width int, value interface{}) string {
+		return fmt.Sprintf("%*v", width, value)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"join":  strings.Join,
+	"json": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+func toFloat(value interface{}) (float64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	}
+	return 0, fmt.Errorf("money of %T", value)
+}
+
+// Parse the --format flag
+func parseFormatFlag(c *cli.Context) error {
+	formatTemplate = nil
+	if c.GlobalString("format") == "" {
+		return nil
+	}
+	format, err := template.New("format").Funcs(formatFuncs).Parse(c.GlobalString("format"))
+	if err != nil {
+		return fmt.Errorf("invalid --format template: %v", err)
+	}
+	formatTemplate = format
+	return nil
+}
+
+// Print the data with the --format template, ending with a newline
+func writeFormatted(data interface{}) error {
+	out := &bytes.Buffer{}
+	if err := formatTemplate.Execute(out, data); err != nil {
+		return err
+	}
+	if out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+		out.WriteString("\n")
+	}
+	_, err := os.Stdout.Write(out.Bytes())
+	return err
+}
+
+// Writer of transactions with the --format template
+type formatWriter struct{}
+
+func (w formatWriter) WriteTransactions(t *n26.Transactions) error {
+	return writeFormatted(t)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+func TestFormatDate(t *testing.T) {
+	date := formatFuncs["date"].(func(interface{}, ...string) (string, error))
+	instant := time.Date(2018, 7, 10, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value interface{}
+		want  string
+	}{
+		{instant, "2018-07-10 12:00"},
+		{n26.TimeStamp{Time: instant}, "2018-07-10 12:00"},
+		{&n26.TimeStamp{Time: instant}, "2018-07-10 12:00"},
+		// N26 milliseconds of the Berlin wall clock
+		{time.Date(2018, 7, 10, 12, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond), "2018-07-10 12:00"},
+		{int64(0), "1970-01-01 00:00"},
+		{n26.TimeStamp{}, ""},
+	}
+	for _, c := range cases {
+		got, err := date(c.value)
+		if err != nil || got != c.want {
+			t.Errorf("%v: got %q, %v, want %q", c.value, got, err, c.want)
+		}
+	}
+	if _, err := date("2018-07-10"); err == nil {
+		t.Error("string: got no error")
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	money := formatFuncs["money"].(func(interface{}, ...string) (string, error))
+	cases := []struct {
+		locale   string
+		amount   interface{}
+		currency []string
+		want     string
+	}{
+		{"", -1234.5, nil, "-1234.50"},
+		{"", 1500, []string{"EUR"}, "1500.00 EUR"},
+		{"", 1234.4, []string{"JPY"}, "1234 JPY"},
+		{"de-DE", -1234.5, nil, "-1.234,50"},
+		{"de-DE", -1234.5, []string{"EUR"}, "-1.234,50 €"},
+		{"en-US", 1234.4, []string{"JPY"}, "¥1,234"},
+	}
+	for _, c := range cases {
+		displayLocale = nil
+		if c.locale != "" {
+			displayLocale, _ = findLocale(c.locale)
+		}
+		got, err := money(c.amount, c.currency...)
+		if err != nil || got != c.want {
+			t.Errorf("%s %v %v: got %q, %v, want %q", c.locale, c.amount, c.currency, got, err, c.want)
+		}
+	}
+	displayLocale = nil
+	if _, err := money("12"); err == nil {
+		t.Error("string: got no error")
+	}
+}
+
+func TestFormatDateLocale(t *testing.T) {
+	date := formatFuncs["date"].(func(interface{}, ...string) (string, error))
+	displayLocale, _ = findLocale("de-DE")
+	defer func() { displayLocale = nil }()
+	instant := time.Date(2018, 7, 10, 10, 0, 0, 0, time.UTC)
+	if got, _ := date(instant); got != "10.07.2018 12:00" {
+		t.Errorf("got %q, want the de-DE date and time", got)
+	}
+	if got, _ := date(instant, "2006"); got != "2018" {
+		t.Errorf("got %q, want the layout", got)
+	}
+}
+
+func TestStatusColumns(t *testing.T) {
+	// N26 milliseconds of the Berlin wall clock
+	created := time.Date(2018, 7, 10, 12, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	_, rows, err := statusColumns.table(columnSelection{}, []*n26.Statuses{{Created: created}})
+	if err != nil || len(rows) != 1 || rows[0][0] != "2018-07-10 12:00:00 +0200 CEST" {
+		t.Errorf("got %q, %v, want the Berlin time", rows, err)
+	}
+}
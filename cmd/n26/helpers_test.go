@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+// Transactions with the characters output formats must escape, newest first
+// as N26 lists them
+func testTransactions() n26.Transactions {
+	day := func(d, hour, min int) n26.TimeStamp {
+		return n26.TimeStamp{Time: time.Date(2018, 3, d, hour, min, 0, 0, n26.Location())}
+	}
+	return n26.Transactions{
+		{ID: "c3", Type: "PT", Amount: -45.9, CurrencyCode: "EUR", OriginalAmount: -50, OriginalCurrency: "USD",
+			VisibleTS: day(17, 17, 43), MerchantName: `Pizza <Place> & "Bar"`, MerchantCity: "New York",
+			Category: "micro-v2-food-groceries", Pending: true},
+		{ID: "b2", Type: "DT", Amount: -800, CurrencyCode: "EUR", VisibleTS: day(2, 9, 0),
+			PartnerName: "Müller & Söhne", PartnerIban: "DE89370400440532013000", PartnerBic: "COBADEFFXXX",
+			ReferenceText: "Rent 03/2018\r\nflat 'A:B/C'", Category: "micro-v2-household-utilities"},
+		{ID: "a1", Type: "CT", Amount: 1500, CurrencyCode: "EUR", VisibleTS: day(1, 0, 0),
+			PartnerName: "ACME GmbH", ReferenceText: "Salary: yes # no", Category: "micro-v2-income"},
+	}
+}
+
+var testBalance = &n26.Balance{AvailableBalance: 1234.5, UsableBalance: 1000, IBAN: "DE74100110012620000000",
+	BIC: "NTSBDEB1XXX", BankName: "N26 Bank"}
+
+// Run f in a command with the flags, parsing the arguments
+func runWithFlags(t *testing.T, flags []cli.Flag, args []string, f func(c *cli.Context)) {
+	runWithGlobals(t, nil, flags, args, f)
+}
+
+// Run f like runWithFlags, with global options of the application given
+// before the command
+func runWithGlobals(t *testing.T, globals []string, flags []cli.Flag, args []string, f func(c *cli.Context)) {
+	app := cli.NewApp()
+	app.Flags = append(append([]cli.Flag{profileFlag, outputFlag}, passwordFlags()...), columnFlags()...)
+	app.Commands = []cli.Command{{Name: "test", Flags: flags, Action: func(c *cli.Context) error {
+		f(c)
+		return nil
+	}}}
+	command := append(append([]string{"n26"}, globals...), "test")
+	if err := app.Run(append(command, args...)); err != nil {
+		t.Fatal(err)
+	}
+}
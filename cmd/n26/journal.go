@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+// Read the account rules of the rules flag, if set
+func readRulesFlag(c *cli.Context) (n26.AccountRules, error) {
+	if c.String("rules") == "" {
+		return nil, nil
+	}
+	file, err := os.Open(c.String("rules"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return n26.ParseAccountRules(file)
+}
+
+type journalWriter struct {
+	out       io.Writer
+	beancount bool
+	account   string
+	rules     n26.AccountRules
+	statementWindow
+}
+
+// NewJournalWriter creates a writer for ledger and hledger journals, or beancount
+// ledgers, of the account of the balance. The counter-accounts are chosen by the
+// rules and the balance assertion at the end is reconstructed from the current
+// balance like for MT940.
+func NewJournalWriter(target io.Writer, beancount bool, account string, rules n26.AccountRules,
+	balance *n26.Balance, from, to n26.TimeStamp, later n26.Transactions) *journalWriter {
+	return &journalWriter{target, beancount, account, rules, statementWindow{balance, from, to, later}}
+}
+
+func (w *journalWriter) WriteTransactions(transactions *n26.Transactions) error {
+	statement := w.statement(*transactions)
+	if w.beancount {
+		return statement.WriteBeancount(w.out, w.account, w.rules)
+	}
+	return statement.WriteLedger(w.out, w.account, w.rules)
+}
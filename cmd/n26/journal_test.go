@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+func TestJournalWriter(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules")
+	if err := ioutil.WriteFile(rulesFile, []byte("partner:ACME = Income:Salary\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var rules n26.AccountRules
+	runWithFlags(t, []cli.Flag{cli.StringFlag{Name: "rules"}}, []string{"--rules", rulesFile}, func(c *cli.Context) {
+		var err error
+		if rules, err = readRulesFlag(c); err != nil {
+			t.Fatal(err)
+		}
+	})
+	march := n26.TimeStamp{Time: time.Date(2018, 3, 1, 0, 0, 0, 0, n26.Location())}
+	endOfMarch := n26.TimeStamp{Time: time.Date(2018, 3, 31, 23, 59, 59, 0, n26.Location())}
+	later := n26.Transactions{{ID: "d4", Type: "CT", Amount: 100, CurrencyCode: "EUR", PartnerName: "Refund",
+		VisibleTS: n26.TimeStamp{Time: time.Date(2018, 4, 2, 12, 0, 0, 0, n26.Location())}}}
+	cases := []struct {
+		name      string
+		beancount bool
+		later     n26.Transactions
+		want      []string
+	}{
+		{"ledger", false, nil, []string{
+			"    Income:Salary                               -1500.00 EUR",
+			"2018-03-31 * Balance assertion\n    Assets:N26                           0 EUR = 1234.50 EUR\n",
+		}},
+		// the balance at the end of the window is the current one less the later transactions
+		{"ledger with later transactions", false, later, []string{
+			"    Assets:N26                           0 EUR = 1134.50 EUR\n",
+		}},
+		{"beancount", true, later, []string{
+			`2018-03-02 * "Müller & Söhne" "Rent 03/2018 flat 'A:B/C'"`,
+			"  Income:Salary                                 -1500.00 EUR",
+			"2018-04-01 balance Assets:N26                    1134.50 EUR\n",
+		}},
+	}
+	for _, c := range cases {
+		transactions := testTransactions()
+		buffer := &bytes.Buffer{}
+		writer := NewJournalWriter(buffer, c.beancount, "Assets:N26", rules, testBalance, march, endOfMarch, c.later)
+		if err := writer.WriteTransactions(&transactions); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range c.want {
+			if !strings.Contains(buffer.String(), want) {
+				t.Errorf("%s: missing %q in\n%s", c.name, want, buffer.String())
+			}
+		}
+	}
+}
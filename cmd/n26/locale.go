@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+var localeFlag = cli.StringFlag{Name: "locale", EnvVar: "N26_LOCALE", Usage: "format amounts and dates of table " +
+	"output and --format templates for a locale, e.g. de-DE or en-GB. Defaults to plain numbers and Go time stamps"}
+
+// The number, currency and date formats of a locale
+type locale struct {
+	decimal, thousands string
+	// whether the currency symbol precedes the amount, and the space between them
+	symbolFirst bool
+	symbolSpace string
+	// Go time layouts
+	date, dateTime string
+}
+
+var locales = map[string]locale{
+	"de-DE": {",", ".", false, "\u00a0", "02.01.2006", "02.01.2006 15:04"},
+	"de-AT": {",", ".", true, "\u00a0", "02.01.2006", "02.01.2006 15:04"},
+	"de-CH": {".", "’", true, "\u00a0", "02.01.2006", "02.01.2006 15:04"},
+	"en-GB": {".", ",", true, "", "02/01/2006", "02/01/2006 15:04"},
+	"en-IE": {".", ",", true, "", "02/01/2006", "02/01/2006 15:04"},
+	"en-US": {".", ",", true, "", "01/02/2006", "01/02/2006 3:04 PM"},
+	"es-ES": {",", ".", false, "\u00a0", "02/01/2006", "02/01/2006 15:04"},
+	"fr-FR": {",", "\u202f", false, "\u00a0", "02/01/2006", "02/01/2006 15:04"},
+	"it-IT": {",", ".", false, "\u00a0", "02/01/2006", "02/01/2006 15:04"},
+	"nl-NL": {",", ".", true, "\u00a0", "02-01-2006", "02-01-2006 15:04"},
+	"pt-PT": {",", "\u00a0", false, "\u00a0", "02/01/2006", "02/01/2006 15:04"},
+}
+
+var currencySymbols = map[string]string{
+	"EUR": "€",
+	"USD": "$",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+// The locale of the --locale flag, nil for plain numbers and time stamps
+var displayLocale *locale
+
+// Set the locale of the --locale flag or of the profile
+func parseLocaleFlag(c *cli.Context) error {
+	displayLocale = nil
+	name := c.GlobalString("locale")
+	if name == "" && activeProfile != nil {
+		name = activeProfile.locale
+	}
+	if name == "" {
+		return nil
+	}
+	l, err := findLocale(name)
+	if err != nil {
+		return err
+	}
+	displayLocale = l
+	return nil
+}
+
+// The locale of the name, accepting de_DE and de-de as well
+func findLocale(name string) (*locale, error) {
+	for known, l := range locales {
+		if strings.EqualFold(strings.Replace(name, "_", "-", 1), known) {
+			l := l
+			return &l, nil
+		}
+	}
+	names := []string{}
+	for known := range locales {
+		names = append(names, known)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown locale %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// An amount of money, shown with its currency in locale formatted output
+type money struct {
+	Amount   float64
+	Currency string
+}
+
+// The digits after the decimal separator of the currencies without two, by ISO 4217
+var currencyMinorUnits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"ISK": 0,
+	"CLP": 0,
+	"VND": 0,
+	"BHD": 3,
+	"JOD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+}
+
+// The digits after the decimal separator of amounts in the currency
+func minorUnits(currency string) int {
+	if digits, ok := currencyMinorUnits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+func (l *locale) number(amount float64, decimals int) string {
+	digits := strconv.FormatFloat(math.Abs(amount), 'f', decimals, 64)
+	integer, fraction := digits, ""
+	if decimals > 0 {
+		integer, fraction = digits[:len(digits)-decimals-1], l.decimal+digits[len(digits)-decimals:]
+	}
+	grouped := &strings.Builder{}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(l.thousands)
+		}
+		grouped.WriteRune(digit)
+	}
+	return grouped.String() + fraction
+}
+
+func (l *locale) money(m money) string {
+	decimals := minorUnits(m.Currency)
+	number := l.number(m.Amount, decimals)
+	sign := ""
+	if math.Round(m.Amount*math.Pow10(decimals)) < 0 {
+		sign = "-"
+	}
+	if m.Currency == "" {
+		return sign + number
+	}
+	symbol, space := currencySymbols[m.Currency], l.symbolSpace
+	if symbol == "" {
+		symbol, space = m.Currency, "\u00a0"
+	}
+	if l.symbolFirst {
+		return sign + symbol + space + number
+	}
+	return sign + number + "\u00a0" + symbol
+}
+
+// A time in the display time zone, without the time of day at midnight
+func (l *locale) time(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	t = t.In(n26.DisplayLocation)
+	if t.Equal(n26.StartOfDay(t)) {
+		return t.Format(l.date)
+	}
+	return t.Format(l.dateTime)
+}
+
+// Whether stdout is a terminal and colours are not switched off by NO_COLOR
+func colorOutput() bool {
+	if _, off := os.LookupEnv("NO_COLOR"); off {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorReset = "\033[0m"
+)
+
+// Colour debits red and credits green
+func colorAmount(amount float64, text string) string {
+	switch cents := math.Round(amount * 100); {
+	case cents < 0:
+		return colorRed + text + colorReset
+	case cents > 0:
+		return colorGreen + text + colorReset
+	}
+	return text
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+func TestLocaleMoney(t *testing.T) {
+	// grouping and sign, symbol position and rounding to zero per locale
+	cases := map[string][3]string{
+		"de-DE": {"-1.234.567,89\u00a0€", "1.234\u00a0¥", "0,00\u00a0€"},
+		"de-AT": {"-€\u00a01.234.567,89", "¥\u00a01.234", "€\u00a00,00"},
+		"de-CH": {"-€\u00a01’234’567.89", "¥\u00a01’234", "€\u00a00.00"},
+		"en-GB": {"-€1,234,567.89", "¥1,234", "€0.00"},
+		"en-IE": {"-€1,234,567.89", "¥1,234", "€0.00"},
+		"en-US": {"-€1,234,567.89", "¥1,234", "€0.00"},
+		"es-ES": {"-1.234.567,89\u00a0€", "1.234\u00a0¥", "0,00\u00a0€"},
+		"fr-FR": {"-1\u202f234\u202f567,89\u00a0€", "1\u202f234\u00a0¥", "0,00\u00a0€"},
+		"it-IT": {"-1.234.567,89\u00a0€", "1.234\u00a0¥", "0,00\u00a0€"},
+		"nl-NL": {"-€\u00a01.234.567,89", "¥\u00a01.234", "€\u00a00,00"},
+		"pt-PT": {"-1\u00a0234\u00a0567,89\u00a0€", "1\u00a0234\u00a0¥", "0,00\u00a0€"},
+	}
+	amounts := [3]money{{-1234567.891, "EUR"}, {1234.4, "JPY"}, {-0.004, "EUR"}}
+	if len(cases) != len(locales) {
+		t.Errorf("got cases for %d locales, want all %d", len(cases), len(locales))
+	}
+	for name, want := range cases {
+		l, err := findLocale(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, m := range amounts {
+			if got := l.money(m); got != want[i] {
+				t.Errorf("%s, %v: got %q, want %q", name, m, got, want[i])
+			}
+		}
+	}
+}
+
+func TestLocaleMinorUnits(t *testing.T) {
+	de, en := locales["de-DE"], locales["en-GB"]
+	cases := []struct {
+		l    locale
+		m    money
+		want string
+	}{
+		{de, money{-1234.6, "JPY"}, "-1.235\u00a0¥"},
+		{de, money{-0.4, "JPY"}, "0\u00a0¥"},
+		{de, money{999.6, "JPY"}, "1.000\u00a0¥"},
+		{en, money{12.3456, "KWD"}, "KWD\u00a012.346"},
+		{de, money{12.5, "CHF"}, "12,50\u00a0CHF"},
+		{en, money{12.5, "CHF"}, "CHF\u00a012.50"},
+		{de, money{-999.999, ""}, "-1.000,00"},
+		{en, money{0.005, "USD"}, "$0.01"},
+	}
+	for _, c := range cases {
+		if got := c.l.money(c.m); got != c.want {
+			t.Errorf("%v: got %q, want %q", c.m, got, c.want)
+		}
+	}
+}
+
+func TestFindLocale(t *testing.T) {
+	for _, name := range []string{"de-DE", "de_DE", "DE-de"} {
+		if l, err := findLocale(name); err != nil || l.decimal != "," {
+			t.Errorf("%s: got %+v, %v", name, l, err)
+		}
+	}
+	if _, err := findLocale("xx-XX"); err == nil {
+		t.Errorf("xx-XX: got no error")
+	}
+}
+
+func TestLocaleTime(t *testing.T) {
+	l := locales["en-US"]
+	cases := []struct {
+		t    time.Time
+		want string
+	}{
+		{time.Date(2018, 3, 17, 0, 0, 0, 0, n26.DisplayLocation), "03/17/2018"},
+		{time.Date(2018, 3, 17, 17, 43, 0, 0, n26.DisplayLocation), "03/17/2018 5:43 PM"},
+		{time.Time{}, ""},
+	}
+	for _, c := range cases {
+		if got := l.time(c.t); got != c.want {
+			t.Errorf("%v: got %q, want %q", c.t, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/guitmz/n26"
+)
+
+// The table columns of the models, see columnRegistry
+
+var balanceColumns = columnRegistry{columns: []column{
+	{"iban", "IBAN", field("IBAN")},
+	{"bic", "BIC", field("BIC")},
+	{"available", "Available Balance", amountField("AvailableBalance", "EUR")},
+	{"usable", "Usable Balance", amountField("UsableBalance", "EUR")},
+}, defaults: 4}
+
+var infoColumns = columnRegistry{columns: []column{
+	{"name", "Full Name", func(item reflect.Value) interface{} {
+		return fmt.Sprintf("%s %s", field("FirstName")(item), field("LastName")(item))
+	}},
+	{"email", "Email", field("Email")},
+	{"phone", "Mobile Phone Number", field("MobilePhoneNumber")},
+}, defaults: 3}
+
+var statusColumns = columnRegistry{columns: []column{
+	{"created", "Created", func(item reflect.Value) interface{} {
+		return n26.TimeStampFromMillis(field("Created")(item).(int64))
+	}},
+}, defaults: 1}
+
+var addressColumns = columnRegistry{columns: []column{
+	{"address", "Address", func(item reflect.Value) interface{} {
+		return fmt.Sprintf("%s %s", field("AddressLine1")(item), field("StreetName")(item))
+	}},
+	{"number", "Number", field("HouseNumberBlock")},
+	{"zipcode", "Zipcode", field("ZipCode")},
+	{"city", "City", field("CityName")},
+	{"type", "Type", field("Type")},
+}, defaults: 5}
+
+var cardColumns = columnRegistry{columns: []column{
+	{"id", "ID", field("ID")},
+	{"name", "Name on Card", field("UsernameOnCard")},
+	{"type", "Type", field("CardType")},
+	{"product-type", "Product type", field("CardProductType")},
+	{"number", "Number", field("MaskedPan")},
+	{"expiration", "Expiration Date", field("ExpirationDate")},
+	{"status", "Status", field("Status")},
+}, defaults: 7}
+
+var limitColumns = columnRegistry{columns: []column{
+	{"limit", "Limit", field("Limit")},
+	{"amount", "Amount", amountField("Amount", "EUR")},
+}, defaults: 2}
+
+var contactColumns = columnRegistry{columns: []column{
+	{"name", "Name", field("Name")},
+	{"iban", "IBAN", field("Account.Iban")},
+	{"bic", "BIC", field("Account.Bic")},
+	{"type", "Type", field("Account.AccountType")},
+}, defaults: 4}
+
+var spaceColumns = columnRegistry{columns: []column{
+	{"name", "Name", field("Name")},
+	{"balance", "Balance", amountField("Balance.AvailableBalance", "EUR")},
+}, defaults: 2}
+
+var statementColumns = columnRegistry{columns: []column{
+	{"id", "ID", field("ID")},
+	{"period", "Period", func(item reflect.Value) interface{} {
+		return fmt.Sprintf("%04d-%02d", field("Year")(item), field("Month")(item))
+	}},
+	{"available", "Available", func(item reflect.Value) interface{} {
+		if visibleTS := field("VisibleTS")(item).(int64); visibleTS != 0 {
+			return statementAvailable(visibleTS).Format("2006-01-02")
+		}
+		return ""
+	}},
+}, defaults: 3}
+
+// The columns of transactions, with the markup of foreign currency
+// transactions over the reference exchange rates
+func transactionColumns(rates *n26.ExchangeRates) columnRegistry {
+	transaction := func(value func(n26.Transaction) interface{}) func(reflect.Value) interface{} {
+		return func(item reflect.Value) interface{} {
+			return value(item.Interface().(n26.Transaction))
+		}
+	}
+	return columnRegistry{columns: []column{
+		{"time", "Time", field("VisibleTS")},
+		{"name", "Name", field("PartnerName")},
+		{"iban", "IBAN", field("PartnerIban")},
+		{"bic", "BIC", field("PartnerBic")},
+		{"merchant", "Merchant", field("MerchantName")},
+		{"location", "Location", transaction(func(t n26.Transaction) interface{} {
+			var location string
+			if t.MerchantCity != "" {
+				location = t.MerchantCity
+				if t.MerchantCountry != 0 {
+					location += ", "
+				}
+			}
+			if t.MerchantCountry != 0 {
+				location += t.MerchantCountry.String()
+			}
+			return location
+		})},
+		{"amount", "Amount", transaction(func(t n26.Transaction) interface{} { return money{t.Amount, t.CurrencyCode} })},
+		{"currency", "Currency", field("CurrencyCode")},
+		{"original-amount", "Original Amount", transaction(func(t n26.Transaction) interface{} {
+			if t.IsForeignCurrency() {
+				return money{t.OriginalAmount, t.OriginalCurrency}
+			}
+			return nil
+		})},
+		{"original-currency", "Original Currency", field("OriginalCurrency")},
+		{"exchange-rate", "Exchange Rate", transaction(func(t n26.Transaction) interface{} {
+			if t.IsForeignCurrency() {
+				return strconv.FormatFloat(t.EffectiveRate(), 'f', 4, 64)
+			}
+			return nil
+		})},
+		{"markup", "Markup", transaction(func(t n26.Transaction) interface{} {
+			if !t.IsForeignCurrency() {
+				return nil
+			}
+			if reference, ok := rates.Rate(t.OriginalCurrency, t.VisibleTS.Time); ok {
+				_, m := t.ConversionCost(reference)
+				return strconv.FormatFloat(m*100, 'f', 2, 64) + "%"
+			}
+			return nil
+		})},
+		{"type", "Type", field("Type")},
+		{"category", "Category", transaction(func(t n26.Transaction) interface{} { return t.CategoryName() })},
+		{"reference", "Reference", field("ReferenceText")},
+		{"id", "ID", field("ID")},
+	}, defaults: 13}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+// The time window of a statement with the balance of its account
+type statementWindow struct {
+	balance  *n26.Balance
+	from, to n26.TimeStamp
+	// transactions after the time window, to reconstruct the closing balance
+	later n26.Transactions
+}
+
+// Build the statement of the window from the transactions written. The opening and
+// closing balances are reconstructed from the current balance, the transactions
+// and the later ones.
+func (w statementWindow) statement(transactions n26.Transactions) n26.AccountStatement {
+	from, to := w.from.Time, w.to.Time
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to
+		for _, transaction := range transactions {
+			if transaction.VisibleTS.Before(from) {
+				from = transaction.VisibleTS.Time
+			}
+		}
+	}
+	all := append(append(n26.Transactions{}, transactions...), w.later...)
+	return n26.NewAccountStatement(w.balance, all, from, to)
+}
+
+type mt940Writer struct {
+	out io.Writer
+	statementWindow
+}
+
+// NewMt940Writer creates a writer for MT940 statements of the account of the balance.
+// The opening and closing balances are reconstructed from the current balance, the
+// transactions written and the later ones.
+func NewMt940Writer(target io.Writer, balance *n26.Balance, from, to n26.TimeStamp, later n26.Transactions) *mt940Writer {
+	return &mt940Writer{target, statementWindow{balance, from, to, later}}
+}
+
+func (w *mt940Writer) WriteTransactions(transactions *n26.Transactions) error {
+	return w.statement(*transactions).WriteMT940(w.out)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+func TestMt940Writer(t *testing.T) {
+	march := n26.TimeStamp{Time: time.Date(2018, 3, 1, 0, 0, 0, 0, n26.Location())}
+	endOfMarch := n26.TimeStamp{Time: time.Date(2018, 3, 31, 23, 59, 59, 0, n26.Location())}
+	later := n26.Transactions{{ID: "d4", Type: "CT", Amount: 100, CurrencyCode: "EUR", PartnerName: "Refund",
+		VisibleTS: n26.TimeStamp{Time: time.Date(2018, 4, 2, 12, 0, 0, 0, n26.Location())}}}
+	today := time.Now().In(n26.Location()).Format("060102")
+	cases := []struct {
+		name     string
+		from, to n26.TimeStamp
+		later    n26.Transactions
+		opening  string
+		closing  string
+	}{
+		// the closing balance is the current one less the later transactions
+		{"closed window", march, endOfMarch, later, ":60F:C180301EUR480,40", ":62F:C180331EUR1134,50"},
+		// from the oldest transaction until now
+		{"open window", n26.TimeStamp{}, n26.TimeStamp{}, nil, ":60F:C180301EUR580,40", ":62F:C" + today + "EUR1234,50"},
+	}
+	for _, c := range cases {
+		transactions := testTransactions()
+		buffer := &bytes.Buffer{}
+		if err := NewMt940Writer(buffer, testBalance, c.from, c.to, c.later).WriteTransactions(&transactions); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(buffer.String(), "\r\n")
+		if len(lines) < 5 || lines[3] != c.opening || lines[len(lines)-3] != c.closing {
+			t.Errorf("%s: got\n%s\nwant %s and %s", c.name, buffer.String(), c.opening, c.closing)
+		}
+		if strings.Count(buffer.String(), ":61:") != len(transactions) {
+			t.Errorf("%s: got\n%s\nwant %d entries", c.name, buffer.String(), len(transactions))
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"regexp"
@@ -20,31 +23,131 @@ const (
 	appVersion = "1.5.6"
 )
 
+// The time zone of times without --timezone
+var defaultDisplayLocation = n26.DisplayLocation
+
+// Ends the program, or only the command run in the shell
+var exit = os.Exit
+
 func check(e error) {
 	if e != nil {
-		log.Fatal(e.Error())
+		if session != nil && n26.IsUnauthorized(e) {
+			// the shell logs in again and repeats the command
+			panic(shellExpired{e})
+		}
+		log.Print(e.Error())
+		exit(1)
 	}
 }
 
 func authentication() (*n26.Client, error) {
-	username := os.Getenv("N26_USERNAME")
+	if session != nil {
+		return session, nil
+	}
+	return authenticate(currentProfile(), true)
+}
+
+// The active profile, an empty one without a config file
+func currentProfile() *profile {
+	if activeProfile == nil {
+		return &profile{}
+	}
+	return activeProfile
+}
+
+// Serializes the prompts and credential commands of concurrent logins
+var credentialMutex sync.Mutex
+
+// Log into the account of the profile, reusing the access token of its token
+// store. Credentials are taken from the password flags and environment
+// variables too if useEnv is set, then from the profile, and prompted for
+// if still missing.
+func authenticate(p *profile, useEnv bool) (*n26.Client, error) {
+	if store := expandHome(p.tokenStore); store != "" {
+		if token, ok := readToken(store); ok {
+			return n26.NewClientWithToken(token), nil
+		}
+	}
+	return login(p, useEnv)
+}
+
+// Log into the account of the profile, keeping the access token in its token
+// store. Used when the stored access token was rejected too.
+func login(p *profile, useEnv bool) (*n26.Client, error) {
+	store := expandHome(p.tokenStore)
+	auth, err := credentials(p, useEnv)
+	if err != nil {
+		return nil, err
+	}
+	token, err := n26.Login(auth)
+	if err != nil {
+		return nil, err
+	}
+	if store != "" {
+		if err := saveToken(store, token); err != nil {
+			fmt.Fprintf(os.Stderr, "[-] Could not keep the access token in %s: %v\n", store, err)
+		}
+	}
+	return n26.NewClientWithToken(token), nil
+}
+
+// The credentials of the profile, see authenticate
+func credentials(p *profile, useEnv bool) (n26.Auth, error) {
+	credentialMutex.Lock()
+	defer credentialMutex.Unlock()
+	env := func(name string) string {
+		if useEnv {
+			return name
+		}
+		return ""
+	}
+	prompt := "N26"
+	if !useEnv {
+		prompt = fmt.Sprintf("N26 (%s)", p.name)
+	}
+	var password string
+	var err error
+	// read a piped password before the prompts read stdin
+	if passwordSource != nil && useEnv {
+		if password, err = passwordSource(); err != nil {
+			return n26.Auth{}, err
+		}
+	}
+	username, err := credential(env("N26_USERNAME"), p.usernameCommand, p.username)
+	if err != nil {
+		return n26.Auth{}, err
+	}
 	if username == "" {
-		fmt.Print("N26 username: ")
+		fmt.Printf("%s username: ", prompt)
 		fmt.Scanln(&username)
 	}
-	password := os.Getenv("N26_PASSWORD")
 	if password == "" {
-		fmt.Print("N26 password: ")
+		if password, err = credential(env("N26_PASSWORD"), p.passwordCommand, ""); err != nil {
+			return n26.Auth{}, err
+		}
+	}
+	if password == "" && p.passwordFile != "" {
+		if password, err = readPasswordFile(p.passwordFile); err != nil {
+			return n26.Auth{}, err
+		}
+	}
+	if password == "" {
+		fmt.Printf("%s password: ", prompt)
 		maskedPass, err := gopass.GetPasswdMasked()
-		check(err)
+		if err != nil {
+			return n26.Auth{}, err
+		}
 		password = string(maskedPass)
 	}
-	deviceToken := os.Getenv("N26_DEVICE_TOKEN")
+	deviceToken, err := credential(env("N26_DEVICE_TOKEN"), p.deviceTokenCommand, p.deviceToken)
+	if err != nil {
+		return n26.Auth{}, err
+	}
 	if deviceToken == "" {
-		fmt.Print("N26 device token (must be in uuid format): ")
+		fmt.Printf("%s device token (must be in uuid format): ", prompt)
 		fmt.Scanln(&deviceToken)
 	}
-	return n26.NewClient(n26.Auth{UserName: username, Password: password, DeviceToken: deviceToken})
+	return n26.Auth{UserName: username, Password: password, DeviceToken: deviceToken}, nil
 }
 
 // Interface for generic data writer that has a header and data table e.g. table writer and csv writer
@@ -63,23 +166,63 @@ func main() {
 	app.Usage = "your N26 Bank financial information on the command line"
 	app.Author = "Guilherme Thomazi"
 	app.Email = "thomazi@linux.com"
+	app.Flags = append([]cli.Flag{profileFlag}, passwordFlags()...)
+	app.Flags = append(app.Flags, outputFlag, formatFlag)
+	app.Flags = append(app.Flags, columnFlags()...)
+	app.Flags = append(app.Flags, localeFlag,
+		cli.StringFlag{Name: "timezone", EnvVar: "N26_TIMEZONE", Usage: "time zone to display times in, " +
+			"e.g. UTC, Local or America/New_York. Defaults to Europe/Berlin, the time zone N26 uses"},
+	)
+	app.Before = func(c *cli.Context) error {
+		// the profile command must work with a broken or unknown profile
+		if c.Args().First() != profileCommand.Name {
+			if err := loadProfile(c); err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+		}
+		// the commands of the shell keep the password it read
+		if session == nil {
+			if err := parsePasswordFlags(c); err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+		}
+		if err := checkOutputFlag(c); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if err := parseFormatFlag(c); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if err := parseLocaleFlag(c); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		n26.DisplayLocation = defaultDisplayLocation
+		if timezone := c.GlobalString("timezone"); timezone != "" {
+			location, err := time.LoadLocation(timezone)
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("unknown time zone %q", timezone), 1)
+			}
+			n26.DisplayLocation = location
+		}
+		return nil
+	}
 	app.Commands = []cli.Command{
 		{
-			Name:  "balance",
-			Usage: "your balance information",
+			Name:      "balance",
+			ArgsUsage: "[json|xlsx]",
+			Usage:     "your balance information",
+			Flags:     []cli.Flag{allProfilesFlag},
 			Action: func(c *cli.Context) error {
+				if c.Bool("all-profiles") {
+					return allProfilesBalance(c)
+				}
 				API, err := authentication()
 				check(err)
-				prettyJSON, balance := API.GetBalance(c.Args().First())
-				if prettyJSON != "" {
-					fmt.Println(prettyJSON)
-				} else {
-					available := strconv.FormatFloat(balance.AvailableBalance, 'f', -1, 64)
-					usable := strconv.FormatFloat(balance.UsableBalance, 'f', -1, 64)
-					data := [][]string{{balance.IBAN, balance.BIC, available, usable}}
-					NewTableWriter().WriteData([]string{"IBAN", "BIC", "Available Balance", "Usable Balance"}, data)
+				balance, err := API.GetBalance()
+				check(err)
+				if xlsxOutput(c) {
+					return writeBalanceXlsx(os.Stdout, balance)
 				}
-				return nil
+				return renderTable(c, balance, []*n26.Balance{balance}, balanceColumns)
 			},
 		},
 		{
@@ -88,14 +231,9 @@ func main() {
 			Action: func(c *cli.Context) error {
 				API, err := authentication()
 				check(err)
-				prettyJSON, info := API.GetInfo(c.Args().First())
-				if prettyJSON != "" {
-					fmt.Println(prettyJSON)
-				} else {
-					data := [][]string{{fmt.Sprintf("%s %s", info.FirstName, info.LastName), info.Email, info.MobilePhoneNumber}}
-					NewTableWriter().WriteData([]string{"Full Name", "Email", "Mobile Phone Number"}, data)
-				}
-				return nil
+				info, err := API.GetInfo()
+				check(err)
+				return renderTable(c, info, []*n26.PersonalInfo{info}, infoColumns)
 			},
 		},
 		{
@@ -104,18 +242,9 @@ func main() {
 			Action: func(c *cli.Context) error {
 				API, err := authentication()
 				check(err)
-				prettyJSON, status := API.GetStatus(c.Args().First())
-				if prettyJSON != "" {
-					fmt.Println(prettyJSON)
-				} else {
-					data := [][]string{
-						{
-							time.Unix(status.Created/1000, 0).String(),
-						},
-					}
-					NewTableWriter().WriteData([]string{"Created"}, data)
-				}
-				return nil
+				status, err := API.GetStatus()
+				check(err)
+				return renderTable(c, status, []*n26.Statuses{status}, statusColumns)
 			},
 		},
 		{
@@ -124,25 +253,9 @@ func main() {
 			Action: func(c *cli.Context) error {
 				API, err := authentication()
 				check(err)
-				prettyJSON, addresses := API.GetAddresses(c.Args().First())
-				if prettyJSON != "" {
-					fmt.Println(prettyJSON)
-				} else {
-					data := [][]string{}
-					for _, address := range addresses.Data {
-						data = append(data,
-							[]string{
-								fmt.Sprintf("%s %s", address.AddressLine1, address.StreetName),
-								address.HouseNumberBlock,
-								address.ZipCode,
-								address.CityName,
-								address.Type,
-							},
-						)
-					}
-					NewTableWriter().WriteData([]string{"Address", "Number", "Zipcode", "City", "Type"}, data)
-				}
-				return nil
+				addresses, err := API.GetAddresses()
+				check(err)
+				return renderTable(c, addresses, addresses.Data, addressColumns)
 			},
 		},
 		// {
@@ -159,27 +272,9 @@ func main() {
 			Action: func(c *cli.Context) error {
 				API, err := authentication()
 				check(err)
-				prettyJSON, cards := API.GetCards(c.Args().First())
-				if prettyJSON != "" {
-					fmt.Println(prettyJSON)
-				} else {
-					data := [][]string{}
-					for _, card := range *cards {
-						data = append(data,
-							[]string{
-								card.ID,
-								card.UsernameOnCard,
-								card.CardType,
-								card.CardProductType,
-								card.MaskedPan,
-								card.ExpirationDate.String(),
-								card.Status,
-							},
-						)
-					}
-					NewTableWriter().WriteData([]string{"ID", "Name on Card", "Type", "Product type", "Number", "Expiration Date", "Status"}, data)
-				}
-				return nil
+				cards, err := API.GetCards()
+				check(err)
+				return renderTable(c, cards, cards, cardColumns)
 			},
 		},
 		{
@@ -188,23 +283,9 @@ func main() {
 			Action: func(c *cli.Context) error {
 				API, err := authentication()
 				check(err)
-				prettyJSON, limits := API.GetLimits(c.Args().First())
-				if prettyJSON != "" {
-					fmt.Println(prettyJSON)
-				} else {
-					data := [][]string{}
-					for _, limit := range *limits {
-						amount := strconv.FormatFloat(limit.Amount, 'f', -1, 64)
-						data = append(data,
-							[]string{
-								limit.Limit,
-								amount,
-							},
-						)
-					}
-					NewTableWriter().WriteData([]string{"Limit", "Amount"}, data)
-				}
-				return nil
+				limits, err := API.GetLimits()
+				check(err)
+				return renderTable(c, limits, limits, limitColumns)
 			},
 		},
 		{
@@ -213,70 +294,58 @@ func main() {
 			Action: func(c *cli.Context) error {
 				API, err := authentication()
 				check(err)
-				prettyJSON, contacts := API.GetContacts(c.Args().First())
-				if prettyJSON != "" {
-					fmt.Println(prettyJSON)
-				} else {
-					data := [][]string{}
-					for _, contact := range *contacts {
-						data = append(data,
-							[]string{
-								contact.Name,
-								contact.Account.Iban,
-								contact.Account.Bic,
-								contact.Account.AccountType,
-							},
-						)
-					}
-					NewTableWriter().WriteData([]string{"Name", "IBAN", "BIC", "Type"}, data)
-				}
-				return nil
+				contacts, err := API.GetContacts()
+				check(err)
+				return renderTable(c, contacts, contacts, contactColumns)
 			},
 		},
 		{
 			Name:      "transactions",
-			Usage:     "list your past transactions. Supports CSV output.",
-			ArgsUsage: "[csv|json|table|smartcsv]",
-			Flags: []cli.Flag{
-				cli.StringFlag{Name: "limit", Value: "10", Usage: "retrieve last N transactions. Default to 10."},
-				cli.StringFlag{Name: "from", Usage: "retrieve transactions from this date. " +
-					"Also 'to' flag needs to be set. Calendar date in the format yyyy-mm-dd. E.g. 2018-03-01"},
-				cli.StringFlag{Name: "to", Usage: "retrieve transactions until this date. " +
-					"Also 'from' flag needs to be set. Calendar date in the format yyyy-mm-dd. E.g. 2018-03-31"},
-			},
+			Usage:     "list your past transactions. Supports CSV, XLSX, OFX, QIF, MT940, ledger, hledger and beancount output.",
+			ArgsUsage: "[table|csv|tsv|json|yaml|ndjson|smartcsv|xlsx|ofx|qif|mt940|ledger|hledger|beancount]",
+			Flags: append(append(transactionFlags(), csvFlags()...), ratesFlag, allProfilesFlag,
+				cli.StringFlag{Name: "qif-dates", Value: "dmy", Usage: "day and month order of QIF dates, dmy or mdy"},
+				cli.StringSliceFlag{Name: "import", Usage: "read the transactions from an N26 CSV `FILE` instead of retrieving them. " +
+					"Repeat to merge several files. The account balance is still retrieved for ofx, mt940 and the plain text accounting formats"},
+				cli.StringFlag{Name: "account", Value: "Assets:N26", Usage: "name of the N26 account in ledger, hledger and beancount output"},
+				cli.StringFlag{Name: "rules", Usage: "`FILE` of rules choosing the counter-accounts of ledger, hledger and beancount " +
+					"postings, one '<field>:<regexp> = <account>' per line with field category, merchant, partner, text or type"},
+			),
 			Action: func(c *cli.Context) (err error) {
-				const dateFormat = "2006-01-02"
-				var from, to n26.TimeStamp
-				if c.IsSet("from") {
-					from.Time, err = time.Parse(dateFormat, c.String("from"))
-					check(err)
+				if c.Bool("all-profiles") {
+					return allProfilesTransactions(c)
+				}
+				from, to, err := transactionRange(c)
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				format := transactionFormat(c)
+				if err := checkFormatArgument(c); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				if format == "smartcsv" && from.IsZero() {
+					return cli.NewExitError("A start time must be set for smart CSV!", 1)
 				}
-				if c.IsSet("to") {
-					to.Time, err = time.Parse(dateFormat, c.String("to"))
+				var API *n26.Client
+				if len(c.StringSlice("import")) == 0 || needsAccount(format) {
+					API, err = authentication()
 					check(err)
 				}
-				API, err := authentication()
-				check(err)
 
-				if c.Args().First() == "smartcsv" {
-					if from.IsZero() || to.IsZero() {
-						fmt.Println("Start and end time must be set for smart CSV!")
-						return nil
-					}
+				if format == "smartcsv" {
 					err = API.GetSmartStatementCsv(from, to, func(r io.Reader) error {
 						_, err := io.Copy(os.Stdout, r)
 						return err
 					})
 					return
 				}
-				writer, err := getTransactionWriter(c.Args().First())
+				writer, err := getTransactionWriter(c, API)
 				check(err)
-				limit := c.String("limit")
 				var transactions *n26.Transactions
-				if !from.IsZero() && !to.IsZero() {
-					transactions, err = API.GetTransactions(from, to, limit)
+				if balancedFormat(format) && len(c.StringSlice("import")) == 0 {
+					transactions, err = windowTransactions(API, from, to)
 				} else {
-					transactions, err = API.GetLastTransactions(limit)
+					transactions, err = fetchTransactions(c, API)
 				}
 				check(err)
 
@@ -287,35 +356,72 @@ func main() {
 		},
 		{
 			Name:      "statements",
-			Usage:     "your statements. Passing one or more space separated statement IDs as argument, downloads the PDF to the current directory",
-			ArgsUsage: "[statement ID]",
+			Usage:     "your statements, oldest first. Passing one or more space separated statement IDs as argument, downloads the PDFs to the output directory",
+			ArgsUsage: "[json|csv|statement ID...]",
+			Flags:     append(statementListFlags(), statementDownloadFlags()...),
+			Subcommands: []cli.Command{
+				{
+					Name:  "camt",
+					Usage: "account statement of the booked transactions of a time window as ISO 20022 camt.053.001.02 XML",
+					Flags: rangeFlags(),
+					Action: func(c *cli.Context) error {
+						if from, _, err := transactionRange(c); err != nil {
+							return cli.NewExitError(err.Error(), 1)
+						} else if from.IsZero() {
+							return cli.NewExitError("A start time must be set for statements!", 1)
+						}
+						API, err := authentication()
+						check(err)
+						statement, err := fetchAccountStatement(c, API)
+						check(err)
+						return statement.WriteCamt053(os.Stdout)
+					},
+				},
+				downloadCommand,
+				verifyCommand,
+			},
 			Action: func(c *cli.Context) error {
+				downloader, err := statementDownloaderFromFlags(c)
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				if _, err := filterStatements(c, nil); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
 				API, err := authentication()
 				check(err)
 				dateRegex := regexp.MustCompile("statement-[0-9][0-9][0-9][0-9]-(1[0-2]|0[1-9]|\\d)")
+				downloads := []statementFile{}
+				listing := !c.Args().Present() && !c.Bool("latest")
 				for _, argument := range c.Args() {
 					switch {
 					case dateRegex.MatchString(argument):
-						API.GetStatementPDF(argument)
-						fmt.Println(fmt.Sprintf("[+] PDF file %s.pdf downloaded!", argument))
+						downloads = append(downloads, statementFileOfID(argument))
 					default:
-						prettyJSON, statements := API.GetStatements(argument)
-						if prettyJSON != "" {
-							fmt.Println(prettyJSON)
-						} else {
-							data := [][]string{}
-							for _, statement := range *statements {
-								data = append(data,
-									[]string{
-										statement.ID,
-									},
-								)
-							}
-							NewTableWriter().WriteData([]string{"ID"}, data)
+						listing = true
+					}
+				}
+				if listing || c.Bool("latest") {
+					all, err := API.GetStatements()
+					check(err)
+					statements, _ := filterStatements(c, *all)
+					if c.Bool("latest") {
+						if len(statements) == 0 {
+							return cli.NewExitError("No statement found!", 1)
+						}
+						latest := statements[len(statements)-1]
+						downloads = append(downloads, newStatementFile(latest.ID, latest.Year, latest.Month))
+					}
+					if listing {
+						if err := renderTable(c, statements, statements, statementColumns); err != nil {
+							return err
 						}
 					}
 				}
-				return nil
+				if len(downloads) == 0 {
+					return nil
+				}
+				return downloader.download(API, downloads)
 			},
 		},
 		{
@@ -325,7 +431,9 @@ func main() {
 			Action: func(c *cli.Context) error {
 				API, err := authentication()
 				check(err)
-				API.BlockCard(c.Args().First())
+				ID := c.Args().First()
+				check(API.BlockCard(ID))
+				fmt.Printf("\nYour card with ID: %s is DISABLED\n\n", ID)
 				return nil
 			},
 		},
@@ -336,36 +444,35 @@ func main() {
 			Action: func(c *cli.Context) error {
 				API, err := authentication()
 				check(err)
-				API.UnblockCard(c.Args().First())
+				ID := c.Args().First()
+				check(API.UnblockCard(ID))
+				fmt.Printf("\nYour card with ID: %s is ACTIVE\n\n", ID)
 				return nil
 			},
 		},
 		{
-			Name:  "spaces",
-			Usage: "your spaces",
+			Name:      "spaces",
+			ArgsUsage: "[json|xlsx]",
+			Usage:     "your spaces",
 			Action: func(c *cli.Context) error {
 				API, err := authentication()
 				check(err)
-				prettyJSON, spaces := API.GetSpaces(c.Args().First())
-				if prettyJSON != "" {
-					fmt.Println(prettyJSON)
-				} else {
-					data := [][]string{}
-					for _, space := range spaces.Spaces {
-						data = append(data,
-							[]string{
-								space.Name,
-								strconv.FormatFloat(space.Balance.AvailableBalance, 'f', -1, 64),
-							},
-						)
-					}
+				spaces, err := API.GetSpaces()
+				check(err)
+				if xlsxOutput(c) {
+					return writeSpacesXlsx(os.Stdout, spaces)
+				}
+				if tableOutput(c) {
 					fmt.Printf("\nYour total balance is: %s\n", strconv.FormatFloat(spaces.TotalBalance, 'f', -1, 64))
 					fmt.Printf("You still have %d available spaces to create and use\n\n", spaces.UserFeatures.AvailableSpaces)
-					NewTableWriter().WriteData([]string{"Name", "Balance"}, data)
 				}
-				return nil
+				return renderTable(c, spaces, spaces.Spaces, spaceColumns)
 			},
 		},
+		reportCommand,
+		profileCommand,
+		tuiCommand,
+		shellCommand,
 	}
 
 	sort.Sort(cli.CommandsByName(app.Commands))
@@ -373,55 +480,308 @@ func main() {
 	check(err)
 }
 
-func getTransactionWriter(outType string) (transactionWriter, error) {
-	if outType == "json" {
-		return jsonWriter{}, nil
+func transactionFlags() []cli.Flag {
+	flags := []cli.Flag{
+		cli.StringFlag{Name: "limit", Value: "10", Usage: "retrieve last N transactions. Default to 10. Filters are applied afterwards."},
+	}
+	flags = append(flags, rangeFlags()...)
+	return append(flags, filterFlags()...)
+}
+
+func rangeFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{Name: "from", Usage: "retrieve transactions from the start of this date. " +
+			"Calendar date in the format yyyy-mm-dd. E.g. 2018-03-01"},
+		cli.StringFlag{Name: "to", Usage: "retrieve transactions until the end of this date. Defaults to now. " +
+			"Calendar date in the format yyyy-mm-dd. E.g. 2018-03-31"},
+		cli.StringFlag{Name: "since", Usage: "retrieve transactions since a date or for a duration until now. E.g. 2018-03-01, 30d, 2w, 6m or 1y"},
+		cli.StringFlag{Name: "month", Usage: "retrieve transactions of a calendar month in the format yyyy-mm. E.g. 2018-03"},
+		cli.StringFlag{Name: "period", Usage: "retrieve transactions of a named period: today, yesterday, this-week, last-week, " +
+			"this-month, last-month, this-quarter, last-quarter, this-year, last-year, q1-q4, yyyy-qN or yyyy"},
+	}
+}
+
+// Parse the time window given by the 'from'/'to', 'since', 'month' or 'period' flags.
+// Days start and end in the N26 time zone. Unset bounds are returned as zero time stamps.
+func transactionRange(c *cli.Context) (from, to n26.TimeStamp, err error) {
+	var set []string
+	for _, name := range []string{"from", "since", "month", "period"} {
+		if c.IsSet(name) {
+			set = append(set, "--"+name)
+		}
+	}
+	if len(set) > 1 {
+		return from, to, fmt.Errorf("only one of %s can be used", strings.Join(set, ", "))
+	}
+	if c.IsSet("to") && !c.IsSet("from") {
+		return from, to, errors.New("--to requires --from")
+	}
+
+	var r n26.DateRange
+	switch {
+	case c.IsSet("from"):
+		var first time.Time
+		if first, err = n26.ParseDate(c.String("from")); err != nil {
+			return
+		}
+		r.From.Time = first
+		if c.IsSet("to") {
+			var last time.Time
+			if last, err = n26.ParseDate(c.String("to")); err != nil {
+				return
+			}
+			r.To.Time = n26.EndOfDay(last)
+		}
+	case c.IsSet("since"):
+		r, err = n26.ParseSince(c.String("since"), time.Now())
+	case c.IsSet("month"):
+		r, err = n26.ParseMonth(c.String("month"))
+	case c.IsSet("period"):
+		r, err = n26.ParsePeriod(c.String("period"), time.Now())
+	}
+	if err == nil && !r.To.IsZero() && r.To.Before(r.From.Time) {
+		err = errors.New("the end of the time window is before its start")
+	}
+	return r.From, r.To, err
+}
+
+// Retrieve the transactions selected by the transaction and filter flags
+func fetchTransactions(c *cli.Context, API *n26.Client) (*n26.Transactions, error) {
+	from, to, err := transactionRange(c)
+	if err != nil {
+		return nil, err
+	}
+	filter, search, err := transactionFilter(c)
+	if err != nil {
+		return nil, err
+	}
+	if files := c.StringSlice("import"); len(files) > 0 {
+		transactions, err := importTransactions(files)
+		if err != nil {
+			return nil, err
+		}
+		if filter == nil {
+			filter = n26.ByTime(from.Time, to.Time)
+		} else {
+			filter = n26.All(n26.ByTime(from.Time, to.Time), filter)
+		}
+		filtered := transactions.Filter(filter)
+		return &filtered, nil
+	}
+	transactions, err := API.SearchTransactions(from, to, c.String("limit"), search)
+	if err != nil {
+		return nil, err
+	}
+	filtered := transactions.Filter(filter)
+	return &filtered, nil
+}
+
+// The most transactions retrieved for formats with balances
+const statementLimit = 10000
+
+// Whether the balances of the format are reconstructed from the transactions,
+// which needs all of them from the start of the time window until now
+func balancedFormat(format string) bool {
+	switch format {
+	case "mt940", "ledger", "hledger", "beancount":
+		return true
+	}
+	return false
+}
+
+// Reject the flags that leave out transactions, which would make the balances
+// of the format wrong
+func checkCompleteWindow(c *cli.Context, format string) error {
+	filter, _, err := transactionFilter(c)
+	if err != nil {
+		return err
+	}
+	if filter != nil || c.IsSet("limit") {
+		return fmt.Errorf("%s needs all transactions of the time window for its balances, "+
+			"--limit and the filter flags can't be used", format)
+	}
+	return nil
+}
+
+// Retrieve all transactions of the time window, failing if there may be more
+// than statementLimit
+func windowTransactions(API *n26.Client, from, to n26.TimeStamp) (*n26.Transactions, error) {
+	transactions, err := API.GetTransactions(from, to, strconv.Itoa(statementLimit))
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsZero() && len(*transactions) >= statementLimit {
+		return nil, fmt.Errorf("%d or more transactions since %s, too many to retrieve them all",
+			statementLimit, from.In(n26.DisplayLocation).Format("2006-01-02"))
+	}
+	return transactions, nil
+}
+
+// Read and merge the transactions of N26 CSV files
+func importTransactions(files []string) (n26.Transactions, error) {
+	transactions := n26.Transactions{}
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		imported, err := n26.ParseSmartStatementCsv(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		transactions = transactions.Merge(imported)
+	}
+	return transactions, nil
+}
+
+// Whether the output format needs the account, besides the transactions
+func needsAccount(format string) bool {
+	switch format {
+	case "smartcsv", "ofx", "mt940", "ledger", "hledger", "beancount":
+		return true
+	}
+	return false
+}
+
+// Retrieve the statement of the booked transactions for the time window given by
+// the range flags. The balances are reconstructed from the current balance, so all
+// transactions from the start of the window until now are retrieved.
+func fetchAccountStatement(c *cli.Context, API *n26.Client) (n26.AccountStatement, error) {
+	from, to, err := transactionRange(c)
+	if err != nil {
+		return n26.AccountStatement{}, err
+	}
+	if from.IsZero() {
+		return n26.AccountStatement{}, errors.New("a start time must be set for statements")
+	}
+	if to.IsZero() {
+		to.Time = time.Now()
+	}
+	transactions, err := windowTransactions(API, from, n26.TimeStamp{})
+	if err != nil {
+		return n26.AccountStatement{}, err
+	}
+	balance, err := API.GetBalance()
+	if err != nil {
+		return n26.AccountStatement{}, err
+	}
+	return n26.NewBookedStatement(balance, *transactions, from.Time, to.Time), nil
+}
+
+// The output format of transactions: the format given as argument, which
+// may be one of the export formats, or else the --output flag.
+func transactionFormat(c *cli.Context) string {
+	if c.Args().Present() {
+		return c.Args().First()
+	}
+	return outputFormat(c)
+}
+
+// The --format template replaces the output format, so it can't be
+// combined with one given as argument
+func checkFormatArgument(c *cli.Context) error {
+	if formatTemplate != nil && c.Args().Present() {
+		return fmt.Errorf("--format can't be combined with %s output", c.Args().First())
+	}
+	return nil
+}
+
+func getTransactionWriter(c *cli.Context, API *n26.Client) (transactionWriter, error) {
+	if err := checkFormatArgument(c); err != nil {
+		return nil, err
+	}
+	if formatTemplate != nil {
+		return formatWriter{}, nil
+	}
+	format := transactionFormat(c)
+	switch format {
+	case "json", "yaml", "ndjson":
+		return encodedWriter{format}, nil
+	case "ofx":
+		from, to, err := transactionRange(c)
+		if err != nil {
+			return nil, err
+		}
+		balance, err := API.GetBalance()
+		if err != nil {
+			return nil, err
+		}
+		return NewOfxWriter(os.Stdout, balance, from, to), nil
+	case "xlsx":
+		from, to, err := transactionRange(c)
+		if err != nil {
+			return nil, err
+		}
+		return NewXlsxWriter(os.Stdout, from, to), nil
+	case "qif":
+		return NewQifWriter(os.Stdout, c.String("qif-dates"))
+	case "mt940", "ledger", "hledger", "beancount":
+		from, to, err := transactionRange(c)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkCompleteWindow(c, format); err != nil {
+			return nil, err
+		}
+		later := &n26.Transactions{}
+		if !to.IsZero() {
+			later, err = windowTransactions(API, n26.TimeStamp{Time: to.Add(time.Millisecond)}, n26.TimeStamp{})
+			if err != nil {
+				return nil, err
+			}
+		}
+		balance, err := API.GetBalance()
+		if err != nil {
+			return nil, err
+		}
+		if format == "mt940" {
+			return NewMt940Writer(os.Stdout, balance, from, to, *later), nil
+		}
+		rules, err := readRulesFlag(c)
+		if err != nil {
+			return nil, err
+		}
+		return NewJournalWriter(os.Stdout, format == "beancount", c.String("account"), rules,
+			balance, from, to, *later), nil
+	}
+	rates, err := readRatesFlag(c)
+	if err != nil {
+		return nil, err
 	}
 	var table dataWriter
-	if outType == "csv" {
-		var err error
-		table, err = NewCsvWriter(os.Stdout)
+	if format == "csv" || format == "tsv" {
+		profile, headers, ok, err := csvProfileFromFlags(c)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return NewCsvProfileWriter(os.Stdout, profile, headers, c.GlobalBool("no-header")), nil
+		}
+		writer, err := NewCsvWriter(os.Stdout)
 		if err != nil {
 			return nil, err
 		}
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		table = writer
 	} else {
 		table = NewTableWriter()
 	}
-	return transactionToStringWriter{table}, nil
+	return transactionToStringWriter{table, transactionColumns(rates), columnSelectionFromFlags(c)}, nil
 }
 
 type transactionToStringWriter struct {
-	out dataWriter
+	out       dataWriter
+	columns   columnRegistry
+	selection columnSelection
 }
 
 func (w transactionToStringWriter) WriteTransactions(transactions *n26.Transactions) error {
-	data := [][]string{}
-	for _, transaction := range *transactions {
-		amount := strconv.FormatFloat(transaction.Amount, 'f', -1, 64)
-		var location string
-		if transaction.MerchantCity != "" {
-			location = transaction.MerchantCity
-			if transaction.MerchantCountry != 0 {
-				location += ", "
-			}
-		}
-		if transaction.MerchantCountry != 0 {
-			location += "Country Code: " + fmt.Sprint(transaction.MerchantCountry)
-		}
-		data = append(data,
-			[]string{
-				transaction.VisibleTS.String(),
-				transaction.PartnerName,
-				transaction.PartnerIban,
-				transaction.PartnerBic,
-				transaction.MerchantName,
-				location,
-				amount,
-				transaction.CurrencyCode,
-				transaction.Type,
-			},
-		)
+	header, data, err := w.columns.table(w.selection, transactions)
+	if err != nil {
+		return err
 	}
-	return w.out.WriteData([]string{"Time", "Name", "IBAN", "BIC", "Merchant", "Location", "Amount", "Currency", "Type"},
-		data)
+	return w.out.WriteData(w.selection.header(header), data)
 }
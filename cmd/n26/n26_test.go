@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/urfave/cli"
+)
+
+func TestCheckCompleteWindow(t *testing.T) {
+	cases := []struct {
+		args []string
+		err  bool
+	}{
+		{nil, false},
+		{[]string{"--month", "2018-03"}, false},
+		{[]string{"--limit", "10"}, true},
+		{[]string{"--category", "groceries"}, true},
+		{[]string{"--pending"}, true},
+		{[]string{"--min", "10"}, true},
+	}
+	for _, c := range cases {
+		runWithFlags(t, transactionFlags(), c.args, func(ctx *cli.Context) {
+			if err := checkCompleteWindow(ctx, "ledger"); (err != nil) != c.err {
+				t.Errorf("%v: got %v, want error %v", c.args, err, c.err)
+			}
+		})
+	}
+}
+
+func TestCheckFormatArgument(t *testing.T) {
+	cases := []struct {
+		format string
+		args   []string
+		err    bool
+	}{
+		{"", []string{"ofx"}, false},
+		{"{{.ID}}", nil, false},
+		{"{{.ID}}", []string{"ofx"}, true},
+		{"{{.ID}}", []string{"table"}, true},
+	}
+	for _, c := range cases {
+		formatTemplate = nil
+		if c.format != "" {
+			formatTemplate = template.Must(template.New("format").Parse(c.format))
+		}
+		runWithFlags(t, nil, c.args, func(ctx *cli.Context) {
+			if err := checkFormatArgument(ctx); (err != nil) != c.err {
+				t.Errorf("%s %v: got %v, want error %v", c.format, c.args, err, c.err)
+			}
+		})
+	}
+	formatTemplate = nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// OFX 2.2 bank statement response, reduced to the elements we fill
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Response struct {
+			Status    ofxStatus `xml:"STATUS"`
+			DTServer  string    `xml:"DTSERVER"`
+			Language  string    `xml:"LANGUAGE"`
+			Financial struct {
+				Org string `xml:"ORG"`
+			} `xml:"FI"`
+		} `xml:"SONRS"`
+	} `xml:"SIGNONMSGSRSV1"`
+	Bank struct {
+		Transaction struct {
+			TrnUID    string       `xml:"TRNUID"`
+			Status    ofxStatus    `xml:"STATUS"`
+			Statement ofxStatement `xml:"STMTRS"`
+		} `xml:"STMTTRNRS"`
+	} `xml:"BANKMSGSRSV1"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxStatement struct {
+	Currency string `xml:"CURDEF"`
+	Account  struct {
+		BankID      string `xml:"BANKID"`
+		AccountID   string `xml:"ACCTID"`
+		AccountType string `xml:"ACCTTYPE"`
+	} `xml:"BANKACCTFROM"`
+	TransactionList struct {
+		Start        string           `xml:"DTSTART"`
+		End          string           `xml:"DTEND"`
+		Transactions []ofxTransaction `xml:"STMTTRN"`
+	} `xml:"BANKTRANLIST"`
+	LedgerBalance    ofxBalance `xml:"LEDGERBAL"`
+	AvailableBalance ofxBalance `xml:"AVAILBAL"`
+}
+
+type ofxTransaction struct {
+	Type     string       `xml:"TRNTYPE"`
+	Posted   string       `xml:"DTPOSTED"`
+	Amount   string       `xml:"TRNAMT"`
+	FitID    string       `xml:"FITID"`
+	Name     string       `xml:"NAME,omitempty"`
+	Memo     string       `xml:"MEMO,omitempty"`
+	Currency *ofxCurrency `xml:"ORIGCURRENCY,omitempty"`
+}
+
+// Original currency of a transaction, the rate is in account currency per original currency
+type ofxCurrency struct {
+	Rate   string `xml:"CURRATE"`
+	Symbol string `xml:"CURSYM"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+type ofxWriter struct {
+	out      io.Writer
+	balance  *n26.Balance
+	from, to n26.TimeStamp
+}
+
+// NewOfxWriter creates a writer for OFX bank statements of the account of the balance.
+// Zero from and to time stamps are replaced by the time of the first and last transaction.
+func NewOfxWriter(target io.Writer, balance *n26.Balance, from, to n26.TimeStamp) *ofxWriter {
+	return &ofxWriter{target, balance, from, to}
+}
+
+// OFX date time in the N26 time zone, e.g. 20180317174344.123[+1:CET]
+func ofxTime(t time.Time) string {
+	t = t.In(n26.Location())
+	name, offset := t.Zone()
+	return fmt.Sprintf("%s[%+d:%s]", t.Format("20060102150405.000"), offset/3600, name)
+}
+
+// Map the N26 transaction type to the OFX one
+func ofxTransactionType(t n26.Transaction) string {
+	switch {
+	case t.Mcc == 6011:
+		return "ATM"
+	case t.Type == "PT" || t.Type == "AA":
+		return "POS"
+	case t.Type == "DD":
+		return "DIRECTDEBIT"
+	case t.Type == "PF":
+		return "FEE"
+	case t.Type == "CT" || t.Type == "DT":
+		return "XFER"
+	case t.Amount < 0:
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// Truncate to at most n characters, as OFX limits the length of some fields
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+func (w *ofxWriter) WriteTransactions(transactions *n26.Transactions) error {
+	now := ofxTime(time.Now())
+	doc := ofxDocument{}
+	doc.SignOn.Response.Status = ofxStatus{0, "INFO"}
+	doc.SignOn.Response.DTServer = now
+	doc.SignOn.Response.Language = "ENG"
+	doc.SignOn.Response.Financial.Org = w.balance.BankName
+	doc.Bank.Transaction.TrnUID = "0"
+	doc.Bank.Transaction.Status = ofxStatus{0, "INFO"}
+
+	statement := &doc.Bank.Transaction.Statement
+	statement.Currency = "EUR"
+	statement.Account.BankID = w.balance.BIC
+	statement.Account.AccountID = w.balance.IBAN
+	statement.Account.AccountType = "CHECKING"
+
+	from, to := w.from, w.to
+	for _, transaction := range *transactions {
+		if from.IsZero() || transaction.VisibleTS.Before(from.Time) {
+			from = transaction.VisibleTS
+		}
+		if w.to.IsZero() && transaction.VisibleTS.After(to.Time) {
+			to = transaction.VisibleTS
+		}
+		if transaction.CurrencyCode != "" {
+			statement.Currency = transaction.CurrencyCode
+		}
+		name := transaction.PartnerName
+		if name == "" {
+			name = transaction.MerchantName
+		}
+		entry := ofxTransaction{
+			Type:   ofxTransactionType(transaction),
+			Posted: ofxTime(transaction.VisibleTS.Time),
+			Amount: strconv.FormatFloat(transaction.Amount, 'f', 2, 64),
+			FitID:  transaction.ID,
+			Name:   truncate(name, 32),
+			Memo:   truncate(transaction.ReferenceText, 255),
+		}
+		if transaction.IsForeignCurrency() {
+			entry.Currency = &ofxCurrency{strconv.FormatFloat(1/transaction.EffectiveRate(), 'f', 6, 64), transaction.OriginalCurrency}
+		}
+		statement.TransactionList.Transactions = append(statement.TransactionList.Transactions, entry)
+	}
+	if to.IsZero() {
+		to = n26.TimeStamp{Time: time.Now()}
+	}
+	if from.IsZero() {
+		from = to
+	}
+	statement.TransactionList.Start = ofxTime(from.Time)
+	statement.TransactionList.End = ofxTime(to.Time)
+	statement.LedgerBalance = ofxBalance{strconv.FormatFloat(w.balance.AvailableBalance, 'f', 2, 64), now}
+	statement.AvailableBalance = ofxBalance{strconv.FormatFloat(w.balance.UsableBalance, 'f', 2, 64), now}
+
+	if _, err := io.WriteString(w.out, ofxHeader); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w.out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.out, "\n")
+	return err
+}
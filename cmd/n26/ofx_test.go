@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+// The times of the writing, which change with every run
+var ofxNow = regexp.MustCompile(`<(DTSERVER|DTASOF)>[^<]*<`)
+
+func TestOfxWriter(t *testing.T) {
+	transactions := testTransactions()
+	buffer := &bytes.Buffer{}
+	if err := NewOfxWriter(buffer, testBalance, n26.TimeStamp{}, n26.TimeStamp{}).WriteTransactions(&transactions); err != nil {
+		t.Fatal(err)
+	}
+	if got := ofxNow.ReplaceAllString(buffer.String(), "<$1>now<"); got != ofxGolden {
+		t.Errorf("got\n%s\nwant\n%s", got, ofxGolden)
+	}
+}
+
+const ofxGolden = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+  <SIGNONMSGSRSV1>
+    <SONRS>
+      <STATUS>
+        <CODE>0</CODE>
+        <SEVERITY>INFO</SEVERITY>
+      </STATUS>
+      <DTSERVER>now</DTSERVER>
+      <LANGUAGE>ENG</LANGUAGE>
+      <FI>
+        <ORG>N26 Bank</ORG>
+      </FI>
+    </SONRS>
+  </SIGNONMSGSRSV1>
+  <BANKMSGSRSV1>
+    <STMTTRNRS>
+      <TRNUID>0</TRNUID>
+      <STATUS>
+        <CODE>0</CODE>
+        <SEVERITY>INFO</SEVERITY>
+      </STATUS>
+      <STMTRS>
+        <CURDEF>EUR</CURDEF>
+        <BANKACCTFROM>
+          <BANKID>NTSBDEB1XXX</BANKID>
+          <ACCTID>DE74100110012620000000</ACCTID>
+          <ACCTTYPE>CHECKING</ACCTTYPE>
+        </BANKACCTFROM>
+        <BANKTRANLIST>
+          <DTSTART>20180301000000.000[+1:CET]</DTSTART>
+          <DTEND>20180317174300.000[+1:CET]</DTEND>
+          <STMTTRN>
+            <TRNTYPE>POS</TRNTYPE>
+            <DTPOSTED>20180317174300.000[+1:CET]</DTPOSTED>
+            <TRNAMT>-45.90</TRNAMT>
+            <FITID>c3</FITID>
+            <NAME>Pizza &lt;Place&gt; &amp; &#34;Bar&#34;</NAME>
+            <ORIGCURRENCY>
+              <CURRATE>0.918000</CURRATE>
+              <CURSYM>USD</CURSYM>
+            </ORIGCURRENCY>
+          </STMTTRN>
+          <STMTTRN>
+            <TRNTYPE>XFER</TRNTYPE>
+            <DTPOSTED>20180302090000.000[+1:CET]</DTPOSTED>
+            <TRNAMT>-800.00</TRNAMT>
+            <FITID>b2</FITID>
+            <NAME>Müller &amp; Söhne</NAME>
+            <MEMO>Rent 03/2018&#xD;&#xA;flat &#39;A:B/C&#39;</MEMO>
+          </STMTTRN>
+          <STMTTRN>
+            <TRNTYPE>XFER</TRNTYPE>
+            <DTPOSTED>20180301000000.000[+1:CET]</DTPOSTED>
+            <TRNAMT>1500.00</TRNAMT>
+            <FITID>a1</FITID>
+            <NAME>ACME GmbH</NAME>
+            <MEMO>Salary: yes # no</MEMO>
+          </STMTTRN>
+        </BANKTRANLIST>
+        <LEDGERBAL>
+          <BALAMT>1234.50</BALAMT>
+          <DTASOF>now</DTASOF>
+        </LEDGERBAL>
+        <AVAILBAL>
+          <BALAMT>1000.00</BALAMT>
+          <DTASOF>now</DTASOF>
+        </AVAILBAL>
+      </STMTRS>
+    </STMTTRNRS>
+  </BANKMSGSRSV1>
+</OFX>
+`
+
+func TestOfxTransaction(t *testing.T) {
+	cases := []struct {
+		name        string
+		transaction n26.Transaction
+		want        []string
+	}{
+		{"escaped", n26.Transaction{Type: "PT", Amount: -1, MerchantName: `<b>Tom & "Jerry's"</b>`},
+			[]string{"<TRNTYPE>POS</TRNTYPE>", "<NAME>&lt;b&gt;Tom &amp; &#34;Jerry&#39;s&#34;&lt;/b&gt;</NAME>"}},
+		{"name cut to 32 characters", n26.Transaction{Type: "DT", Amount: -1,
+			PartnerName: "Überweisungsempfänger mit einem sehr langen Namen"},
+			[]string{"<NAME>Überweisungsempfänger mit einem </NAME>"}},
+		{"newline in memo", n26.Transaction{Type: "CT", Amount: 1, ReferenceText: "a\nb"},
+			[]string{"<TRNTYPE>XFER</TRNTYPE>", "<MEMO>a&#xA;b</MEMO>"}},
+		{"ATM", n26.Transaction{Type: "PT", Mcc: 6011, Amount: -50}, []string{"<TRNTYPE>ATM</TRNTYPE>"}},
+		{"fee", n26.Transaction{Type: "PF", Amount: -2}, []string{"<TRNTYPE>FEE</TRNTYPE>"}},
+		{"other credit", n26.Transaction{Type: "XX", Amount: 2}, []string{"<TRNTYPE>CREDIT</TRNTYPE>"}},
+	}
+	for _, c := range cases {
+		c.transaction.VisibleTS = n26.TimeStamp{Time: time.Date(2018, 3, 17, 0, 0, 0, 0, n26.Location())}
+		buffer := &bytes.Buffer{}
+		transactions := n26.Transactions{c.transaction}
+		if err := NewOfxWriter(buffer, testBalance, n26.TimeStamp{}, n26.TimeStamp{}).WriteTransactions(&transactions); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range c.want {
+			if !strings.Contains(buffer.String(), want) {
+				t.Errorf("%s: missing %s in\n%s", c.name, want, buffer.String())
+			}
+		}
+		if err := xml.Unmarshal(buffer.Bytes()[len(ofxHeader):], &ofxDocument{}); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+}
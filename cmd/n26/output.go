@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+// The formats of the --output flag
+var outputFormats = []string{"table", "json", "csv", "yaml", "tsv", "ndjson", "xlsx"}
+
+var outputFlag = cli.StringFlag{Name: "output, o", EnvVar: "N26_OUTPUT", Usage: "output format of every command: " +
+	"table, json, csv, yaml, tsv, ndjson or xlsx. Defaults to table"}
+
+func isOutputFormat(format string) bool {
+	for _, known := range outputFormats {
+		if format == known {
+			return true
+		}
+	}
+	return false
+}
+
+// The output format of the command: a format given as argument, as in
+// 'n26 balance json', or else the --output flag.
+func outputFormat(c *cli.Context) string {
+	if format := c.Args().First(); isOutputFormat(format) {
+		return format
+	}
+	if format := c.GlobalString("output"); format != "" {
+		return format
+	}
+	if activeProfile != nil && activeProfile.output != "" {
+		return activeProfile.output
+	}
+	return "table"
+}
+
+// The result of a command. Table, CSV and TSV show the header and rows,
+// the other formats encode the data, or the rows if there is no data.
+type result struct {
+	data   interface{}
+	header []string
+	rows   [][]string
+}
+
+// Write the result in the output format of the command
+func render(c *cli.Context, r result) error {
+	if formatTemplate != nil {
+		if r.data == nil {
+			return writeFormatted(rowMaps(r.header, r.rows))
+		}
+		return writeFormatted(r.data)
+	}
+	selection := columnSelectionFromFlags(c)
+	switch format := outputFormat(c); format {
+	case "csv", "tsv":
+		writer, err := NewCsvWriter(os.Stdout)
+		if err != nil {
+			return err
+		}
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		if err := writer.WriteData(selection.header(r.header), r.rows); err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	case "json", "yaml", "ndjson":
+		data := r.data
+		if data == nil {
+			data = rowObjects(r.header, r.rows)
+		}
+		return writeEncoded(os.Stdout, format, data)
+	case "xlsx":
+		name := c.Command.Name
+		if name != "" {
+			name = strings.ToUpper(name[:1]) + name[1:]
+		}
+		return writeRowsXlsx(os.Stdout, name, selection.header(r.header), r.rows)
+	}
+	return NewTableWriter().WriteData(selection.header(r.header), r.rows)
+}
+
+// Write the items of the data as table with the selected columns, or the data
+// in the output format of the command
+func renderTable(c *cli.Context, data, items interface{}, columns columnRegistry) error {
+	r, err := tableResult(c, data, items, columns)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return render(c, r)
+}
+
+// Write a table of strings, such as a report, with the selected columns
+func renderRows(c *cli.Context, header []string, rows [][]string) error {
+	r, err := rowsResult(c, header, rows)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return render(c, r)
+}
+
+// Whether the command writes a table, to add explanations around it
+func tableOutput(c *cli.Context) bool {
+	return formatTemplate == nil && outputFormat(c) == "table"
+}
+
+// Whether the command writes an XLSX workbook, for those with a layout of
+// their own
+func xlsxOutput(c *cli.Context) bool {
+	return formatTemplate == nil && outputFormat(c) == "xlsx"
+}
+
+// Write the data as indented JSON, YAML or one JSON line per list element
+func writeEncoded(w io.Writer, format string, data interface{}) error {
+	switch format {
+	case "yaml":
+		return writeYaml(w, data)
+	case "ndjson":
+		return writeNdjson(w, data)
+	}
+	formatted, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(formatted))
+	return err
+}
+
+// Writer of transactions encoded as JSON, YAML or NDJSON
+type encodedWriter struct {
+	format string
+}
+
+func (w encodedWriter) WriteTransactions(t *n26.Transactions) error {
+	return writeEncoded(os.Stdout, w.format, t)
+}
+
+// A JSON object with its fields in order
+type object []objectField
+
+type objectField struct {
+	key   string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	out := &bytes.Buffer{}
+	out.WriteString("{")
+	for i, field := range o {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		key, _ := json.Marshal(field.key)
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(key)
+		out.WriteString(":")
+		out.Write(value)
+	}
+	out.WriteString("}")
+	return out.Bytes(), nil
+}
+
+// The rows of a table as objects with the headers as keys
+func rowObjects(header []string, rows [][]string) []object {
+	objects := []object{}
+	for _, row := range rows {
+		o := object{}
+		for i, key := range header {
+			if i < len(row) {
+				o = append(o, objectField{key, row[i]})
+			}
+		}
+		objects = append(objects, o)
+	}
+	return objects
+}
+
+// The rows of a table as maps from the headers, for templates
+func rowMaps(header []string, rows [][]string) []map[string]string {
+	maps := []map[string]string{}
+	for _, row := range rows {
+		m := map[string]string{}
+		for i, key := range header {
+			if i < len(row) {
+				m[key] = row[i]
+			}
+		}
+		maps = append(maps, m)
+	}
+	return maps
+}
+
+func writeNdjson(w io.Writer, data interface{}) error {
+	encoder := json.NewEncoder(w)
+	list := reflect.ValueOf(data)
+	for list.Kind() == reflect.Ptr {
+		list = list.Elem()
+	}
+	if list.Kind() != reflect.Slice {
+		return encoder.Encode(data)
+	}
+	for i := 0; i < list.Len(); i++ {
+		if err := encoder.Encode(list.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write the data as YAML. It is encoded as JSON first, so that the YAML
+// has the same keys in the same order.
+func writeYaml(w io.Writer, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	value, err := readJSONValue(decoder)
+	if err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlNode(value)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Decode a JSON value keeping the order of object fields
+func readJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		o := object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, objectField{key.(string), value})
+		}
+		_, err = decoder.Token()
+		return o, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := readJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = decoder.Token()
+		return list, err
+	}
+	return token, nil
+}
+
+// The YAML node of a decoded JSON value, with the tag of its JSON type so
+// that strings like "yes" or "0123" stay strings
+func yamlNode(value interface{}) *yaml.Node {
+	switch v := value.(type) {
+	case object:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, field := range v {
+			node.Content = append(node.Content, yamlNode(field.key), yamlNode(field.value))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, element := range v {
+			node.Content = append(node.Content, yamlNode(element))
+		}
+		return node
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: v.String()}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: v.String()}
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(value)}
+	if yamlOldBool.MatchString(node.Value) {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node
+}
+
+// Strings YAML 1.1 parsers read as booleans
+var yamlOldBool = regexp.MustCompile(`(?i)^(yes|no|on|off|y|n)$`)
+
+// Check the --output flag
+func checkOutputFlag(c *cli.Context) error {
+	if format := c.GlobalString("output"); format != "" && !isOutputFormat(format) {
+		return fmt.Errorf("unknown output format %q, expected %s", format, strings.Join(outputFormats, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteYamlScalars(t *testing.T) {
+	values := []interface{}{nil, true, -12.5, int64(3), "New York", "a@b.c", "micro-v2-food-groceries", "",
+		"yes", "No", "y", "on", "null", "TRUE", "0123", "2018-03-01", "1e3", " leading", "trailing ", "a: b",
+		"a #b", "-1", "~", "*alias", "&anchor", "!tag", "<&>", `say "hi"`, "a\r\nb", "Müller", "- item", "[1]"}
+	for _, value := range values {
+		buffer := &bytes.Buffer{}
+		if err := writeYaml(buffer, value); err != nil {
+			t.Fatal(err)
+		}
+		var decoded interface{}
+		if err := yaml.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Errorf("%#v: %v in %s", value, err, buffer.String())
+			continue
+		}
+		if f, ok := decoded.(float64); ok {
+			decoded = f
+		} else if i, ok := decoded.(int); ok {
+			decoded = int64(i)
+		}
+		if decoded != value {
+			t.Errorf("%#v: got %s, decoded as %#v", value, buffer.String(), decoded)
+		}
+	}
+	// booleans of YAML 1.1 parsers are quoted too
+	for _, value := range []string{"yes", "No", "y", "on", "OFF"} {
+		buffer := &bytes.Buffer{}
+		if writeYaml(buffer, value); buffer.String() != `"`+value+"\"\n" {
+			t.Errorf("%s: got %s", value, buffer.String())
+		}
+	}
+}
+
+type yamlTestItem struct {
+	Name   string            `json:"name"`
+	Tags   []string          `json:"tags"`
+	Labels map[string]string `json:"labels"`
+	Child  *yamlTestItem     `json:"child,omitempty"`
+}
+
+func TestWriteYaml(t *testing.T) {
+	cases := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"empty object", struct{}{}, "{}\n"},
+		{"empty list", []string{}, "[]\n"},
+		{"scalar", "yes", "\"yes\"\n"},
+		{"nested", &yamlTestItem{Name: "top", Tags: []string{"a", "no"}, Labels: map[string]string{},
+			Child: &yamlTestItem{Name: "a: b", Labels: map[string]string{"k": "v"}}},
+			`name: top
+tags:
+  - a
+  - "no"
+labels: {}
+child:
+  name: 'a: b'
+  tags: null
+  labels:
+    k: v
+`},
+		{"objects in a list", []interface{}{
+			yamlTestItem{Name: "first", Tags: []string{}},
+			struct{}{},
+			[]int{1, 2},
+			[]int{},
+		}, `- name: first
+  tags: []
+  labels: null
+- {}
+- - 1
+  - 2
+- []
+`},
+	}
+	for _, c := range cases {
+		buffer := &bytes.Buffer{}
+		if err := writeYaml(buffer, c.data); err != nil {
+			t.Fatal(err)
+		}
+		if buffer.String() != c.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", c.name, buffer.String(), c.want)
+		}
+	}
+}
+
+func TestWriteYamlTransactions(t *testing.T) {
+	transactions := testTransactions()
+	buffer := &bytes.Buffer{}
+	if err := writeYaml(buffer, transactions[:2]); err != nil {
+		t.Fatal(err)
+	}
+	lines := map[string]bool{}
+	for _, line := range bytes.Split(buffer.Bytes(), []byte("\n")) {
+		lines[string(line)] = true
+	}
+	for _, want := range []string{
+		"- id: c3",
+		`  merchantName: Pizza <Place> & "Bar"`,
+		"  merchantCity: New York",
+		"- id: b2",
+		"  partnerName: Müller & Söhne",
+		`  referenceText: "Rent 03/2018\r\nflat 'A:B/C'"`,
+	} {
+		if !lines[want] {
+			t.Errorf("missing line %s in\n%s", want, buffer.String())
+		}
+	}
+}
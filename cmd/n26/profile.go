@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+var profileFlag = cli.StringFlag{Name: "profile", EnvVar: "N26_PROFILE", Usage: "profile of the config file to use, " +
+	"instead of its default profile. See 'n26 profile'"}
+
+// The profile chosen by --profile or the default profile of the config file, nil if there is none
+var activeProfile *profile
+
+// Load the profile chosen by --profile or the default profile
+func loadProfile(c *cli.Context) error {
+	activeProfile = nil
+	cfg, err := readConfig(configPath())
+	if err != nil {
+		return err
+	}
+	name := c.GlobalString("profile")
+	if name == "" {
+		name = cfg.defaultProfile
+	}
+	if name == "" {
+		return nil
+	}
+	if activeProfile = cfg.profile(name); activeProfile == nil {
+		return fmt.Errorf("unknown profile %q, see 'n26 profile list'", name)
+	}
+	if activeProfile.output != "" && !isOutputFormat(activeProfile.output) {
+		return fmt.Errorf("unknown output format %q in profile %q", activeProfile.output, name)
+	}
+	return nil
+}
+
+var profileCommand = cli.Command{
+	Name: "profile",
+	Usage: "manage the profiles of the config file, one per N26 account. Choose one with --profile or N26_PROFILE, " +
+		"the default profile is used otherwise",
+	Subcommands: []cli.Command{
+		{
+			Name:  "list",
+			Usage: "list the profiles",
+			Action: func(c *cli.Context) error {
+				cfg, err := readConfig(configPath())
+				check(err)
+				return renderRows(c, []string{"Name", "Default", "Username", "Device Token", "Output", "Locale", "Token Store"},
+					profileRows(cfg))
+			},
+		},
+		{
+			Name:      "add",
+			Usage:     "add a profile or change the settings of one. A device token is generated for new profiles if not given",
+			ArgsUsage: "<name>",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "username", Usage: "N26 user email"},
+				cli.StringFlag{Name: "device-token", Usage: "device token in UUID format"},
+				cli.StringFlag{Name: "output", Usage: "default output format: table, json, csv, yaml, tsv or ndjson"},
+				cli.StringFlag{Name: "locale", Usage: "default locale of table output, e.g. de-DE"},
+				cli.StringFlag{Name: "token-store", Usage: "`FILE` to keep the access token in, to log in only when it expired"},
+				cli.StringFlag{Name: "username-command", Usage: "shell `COMMAND` printing the username"},
+				cli.StringFlag{Name: "password-command", Usage: "shell `COMMAND` printing the password, e.g. 'pass show n26'"},
+				cli.StringFlag{Name: "password-file", Usage: "`FILE` holding the password, readable by the user only"},
+				cli.StringFlag{Name: "device-token-command", Usage: "shell `COMMAND` printing the device token"},
+				cli.BoolFlag{Name: "default", Usage: "use the profile by default"},
+			},
+			Action: func(c *cli.Context) error {
+				name := c.Args().First()
+				if name == "" {
+					return cli.NewExitError("A profile name must be given!", 1)
+				}
+				if output := c.String("output"); output != "" && !isOutputFormat(output) {
+					return cli.NewExitError(fmt.Sprintf("Unknown output format %q!", output), 1)
+				}
+				if name := c.String("locale"); name != "" {
+					if _, err := findLocale(name); err != nil {
+						return cli.NewExitError(err.Error(), 1)
+					}
+				}
+				path := configPath()
+				cfg, err := readConfig(path)
+				check(err)
+				p := cfg.profile(name)
+				if p == nil {
+					p = &profile{name: name}
+					cfg.profiles = append(cfg.profiles, p)
+					if !c.IsSet("device-token") && !c.IsSet("device-token-command") {
+						p.deviceToken, err = newDeviceToken()
+						check(err)
+					}
+				}
+				for _, k := range profileKeys {
+					if flag := flagOfKey(k.key); c.IsSet(flag) {
+						*k.field(p) = c.String(flag)
+					}
+				}
+				if c.Bool("default") || cfg.defaultProfile == "" {
+					cfg.defaultProfile = name
+				}
+				check(saveConfig(path, cfg))
+				fmt.Printf("[+] Profile %s saved to %s\n", name, path)
+				return nil
+			},
+		},
+		{
+			Name:      "remove",
+			Usage:     "remove a profile",
+			ArgsUsage: "<name>",
+			Action: func(c *cli.Context) error {
+				name := c.Args().First()
+				path := configPath()
+				cfg, err := readConfig(path)
+				check(err)
+				if cfg.profile(name) == nil {
+					return cli.NewExitError(fmt.Sprintf("Unknown profile %q!", name), 1)
+				}
+				profiles := []*profile{}
+				for _, p := range cfg.profiles {
+					if p.name != name {
+						profiles = append(profiles, p)
+					}
+				}
+				cfg.profiles = profiles
+				if cfg.defaultProfile == name {
+					cfg.defaultProfile = ""
+				}
+				check(saveConfig(path, cfg))
+				fmt.Printf("[-] Profile %s removed from %s\n", name, path)
+				return nil
+			},
+		},
+	},
+}
+
+// The flag of 'profile add' setting the config key
+func flagOfKey(key string) string {
+	flag := []byte(key)
+	for i, b := range flag {
+		if b == '_' {
+			flag[i] = '-'
+		}
+	}
+	return string(flag)
+}
+
+// A random UUID, version 4
+func newDeviceToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// The access token kept in the file, if still valid
+func readToken(path string) (*n26.Token, bool) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	token := &n26.Token{}
+	if err := json.Unmarshal(content, token); err != nil || !token.Valid() {
+		return nil, false
+	}
+	return token, true
+}
+
+// Keep the access token in the file, readable by the user only
+func saveToken(path string, token *n26.Token) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	content, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	// an existing file keeps its mode otherwise
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// The rows of profile list. The device token is a credential, only whether
+// it is set or printed by a command is shown.
+func profileRows(cfg *config) [][]string {
+	rows := [][]string{}
+	for _, p := range cfg.profiles {
+		isDefault := ""
+		if p.name == cfg.defaultProfile {
+			isDefault = "yes"
+		}
+		deviceToken := ""
+		if p.deviceToken != "" {
+			deviceToken = "set"
+		} else if p.deviceTokenCommand != "" {
+			deviceToken = "command"
+		}
+		rows = append(rows, []string{p.name, isDefault, p.username, deviceToken, p.output, p.locale, p.tokenStore})
+	}
+	return rows
+}
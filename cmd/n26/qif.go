@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/guitmz/n26"
+)
+
+// Date layouts supported by QIF importers, by day/month order
+var qifDateLayouts = map[string]string{
+	"dmy": "02/01/2006",
+	"mdy": "01/02/2006",
+}
+
+type qifWriter struct {
+	out        io.Writer
+	dateLayout string
+}
+
+// NewQifWriter creates a writer for QIF bank transactions. The date order is either 'dmy' or 'mdy'.
+func NewQifWriter(target io.Writer, dateOrder string) (*qifWriter, error) {
+	layout, ok := qifDateLayouts[strings.ToLower(dateOrder)]
+	if !ok {
+		return nil, fmt.Errorf("unknown QIF date order %q, expected dmy or mdy", dateOrder)
+	}
+	return &qifWriter{target, layout}, nil
+}
+
+// QIF fields are single lines, categories must not contain the
+// subcategory and class separators
+var (
+	qifLine     = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+	qifCategory = strings.NewReplacer(":", " ", "/", " ")
+)
+
+func (w *qifWriter) WriteTransactions(transactions *n26.Transactions) error {
+	out := bufio.NewWriter(w.out)
+	fmt.Fprintln(out, "!Type:Bank")
+	for _, transaction := range *transactions {
+		payee := transaction.PartnerName
+		if payee == "" {
+			payee = transaction.MerchantName
+		}
+		fmt.Fprintf(out, "D%s\n", transaction.VisibleTS.In(n26.Location()).Format(w.dateLayout))
+		fmt.Fprintf(out, "T%s\n", strconv.FormatFloat(transaction.Amount, 'f', 2, 64))
+		if !transaction.Pending {
+			fmt.Fprintln(out, "C*")
+		}
+		if payee != "" {
+			fmt.Fprintf(out, "P%s\n", qifLine.Replace(payee))
+		}
+		if transaction.ReferenceText != "" {
+			fmt.Fprintf(out, "M%s\n", qifLine.Replace(transaction.ReferenceText))
+		}
+		if category := transaction.CategoryName(); category != "" {
+			fmt.Fprintf(out, "L%s\n", qifCategory.Replace(qifLine.Replace(category)))
+		}
+		fmt.Fprintln(out, "^")
+	}
+	return out.Flush()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+func TestQifWriter(t *testing.T) {
+	cases := []struct {
+		dateOrder string
+		want      string
+	}{
+		{"dmy", `!Type:Bank
+D17/03/2018
+T-45.90
+PPizza <Place> & "Bar"
+LFood & Groceries
+^
+D02/03/2018
+T-800.00
+C*
+PMüller & Söhne
+MRent 03/2018 flat 'A:B/C'
+LHousehold & Utilities
+^
+D01/03/2018
+T1500.00
+C*
+PACME GmbH
+MSalary: yes # no
+LIncome
+^
+`},
+		{"MDY", `!Type:Bank
+D03/17/2018
+T-45.90
+PPizza <Place> & "Bar"
+LFood & Groceries
+^
+D03/02/2018
+T-800.00
+C*
+PMüller & Söhne
+MRent 03/2018 flat 'A:B/C'
+LHousehold & Utilities
+^
+D03/01/2018
+T1500.00
+C*
+PACME GmbH
+MSalary: yes # no
+LIncome
+^
+`},
+	}
+	for _, c := range cases {
+		transactions := testTransactions()
+		buffer := &bytes.Buffer{}
+		writer, err := NewQifWriter(buffer, c.dateOrder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WriteTransactions(&transactions); err != nil {
+			t.Fatal(err)
+		}
+		if buffer.String() != c.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", c.dateOrder, buffer.String(), c.want)
+		}
+	}
+	if _, err := NewQifWriter(&bytes.Buffer{}, "ymd"); err == nil {
+		t.Error("ymd: got no error")
+	}
+}
+
+func TestQifFields(t *testing.T) {
+	cases := []struct {
+		name        string
+		transaction n26.Transaction
+		want        string
+	}{
+		{"partner before merchant", n26.Transaction{Amount: 1, PartnerName: "Partner", MerchantName: "Merchant"},
+			"!Type:Bank\nD17/03/2018\nT1.00\nC*\nPPartner\n^\n"},
+		{"line breaks", n26.Transaction{Amount: -0.5, Pending: true, MerchantName: "a\r\nb\nc\rd", ReferenceText: "x\ny"},
+			"!Type:Bank\nD17/03/2018\nT-0.50\nPa b c d\nMx y\n^\n"},
+		{"category separators", n26.Transaction{Amount: 1, Category: "micro-v2-unknown:a/b"},
+			"!Type:Bank\nD17/03/2018\nT1.00\nC*\nLunknown a b\n^\n"},
+	}
+	for _, c := range cases {
+		// late in the evening, still the same day in Berlin
+		c.transaction.VisibleTS = n26.TimeStamp{Time: time.Date(2018, 3, 17, 23, 30, 0, 0, n26.Location()).UTC()}
+		transactions := n26.Transactions{c.transaction}
+		buffer := &bytes.Buffer{}
+		writer, _ := NewQifWriter(buffer, "dmy")
+		if err := writer.WriteTransactions(&transactions); err != nil {
+			t.Fatal(err)
+		}
+		if buffer.String() != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, buffer.String(), c.want)
+		}
+	}
+}
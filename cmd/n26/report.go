@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+var ratesFlag = cli.StringFlag{Name: "rates", Usage: "file with reference exchange rates to compare foreign " +
+	"currency transactions against. ECB XML or CSV format, e.g. eurofxref-hist.xml"}
+
+// Read the exchange rates given by the 'rates' flag. Returns an empty table if the flag is not set.
+func readRatesFlag(c *cli.Context) (*n26.ExchangeRates, error) {
+	if c.String("rates") == "" {
+		return &n26.ExchangeRates{}, nil
+	}
+	file, err := os.Open(c.String("rates"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return n26.ReadExchangeRates(file)
+}
+
+var reportCommand = cli.Command{
+	Name:  "report",
+	Usage: "summary reports over your transactions",
+	Subcommands: []cli.Command{
+		{
+			Name:  "abroad",
+			Usage: "card spending outside of your home country, per country",
+			Flags: append(transactionFlags(),
+				cli.StringFlag{Name: "home", Usage: "home country as ISO 3166 alpha-2 code, e.g. DE. Defaults to the country of your IBAN"},
+			),
+			Action: func(c *cli.Context) error {
+				API, err := authentication()
+				check(err)
+				home := strings.ToUpper(c.String("home"))
+				if home == "" {
+					balance, err := API.GetBalance()
+					check(err)
+					if len(balance.IBAN) >= 2 {
+						home = balance.IBAN[:2]
+					}
+				}
+				homeCountry, ok := n26.CountryByAlpha2(home)
+				if !ok {
+					return fmt.Errorf("unknown home country %q", home)
+				}
+				transactions, err := fetchTransactions(c, API)
+				check(err)
+
+				header, data, total := spendingAbroad(transactions, homeCountry)
+				if tableOutput(c) {
+					fmt.Printf("\nSpending outside of %s: %s\n\n", homeCountry.Name(), strconv.FormatFloat(total, 'f', 2, 64))
+				}
+				return renderRows(c, header, data)
+			},
+		},
+		{
+			Name:  "fx",
+			Usage: "foreign currency conversion cost per currency and month",
+			Flags: append(transactionFlags(), ratesFlag),
+			Action: func(c *cli.Context) error {
+				rates, err := readRatesFlag(c)
+				check(err)
+				API, err := authentication()
+				check(err)
+				transactions, err := fetchTransactions(c, API)
+				check(err)
+
+				header, data := conversionCosts(transactions, rates)
+				return renderRows(c, header, data)
+			},
+		},
+	},
+}
+
+// Sum up the foreign currency transactions per month and currency and compare them against the reference rates.
+// Transactions without a reference rate are counted but do not contribute to the cost.
+func conversionCosts(transactions *n26.Transactions, rates *n26.ExchangeRates) ([]string, [][]string) {
+	type fxKey struct {
+		month    string
+		currency string
+	}
+	type fxSum struct {
+		count          int
+		originalAmount float64
+		amount         float64
+		reference      float64
+		cost           float64
+		missing        int
+	}
+	sums := map[fxKey]*fxSum{}
+	for _, transaction := range *transactions {
+		if !transaction.IsForeignCurrency() {
+			continue
+		}
+		key := fxKey{transaction.VisibleTS.Format("2006-01"), transaction.OriginalCurrency}
+		if sums[key] == nil {
+			sums[key] = &fxSum{}
+		}
+		sum := sums[key]
+		sum.count++
+		sum.originalAmount += transaction.OriginalAmount
+		sum.amount += transaction.Amount
+		rate, ok := rates.Rate(transaction.OriginalCurrency, transaction.VisibleTS.Time)
+		if !ok {
+			sum.missing++
+			continue
+		}
+		cost, _ := transaction.ConversionCost(rate)
+		sum.reference += transaction.Amount + cost
+		sum.cost += cost
+	}
+	keys := make([]fxKey, 0, len(sums))
+	for key := range sums {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].month != keys[j].month {
+			return keys[i].month < keys[j].month
+		}
+		return keys[i].currency < keys[j].currency
+	})
+	data := [][]string{}
+	for _, key := range keys {
+		sum := sums[key]
+		var reference, cost, markup string
+		if sum.missing < sum.count {
+			reference = strconv.FormatFloat(sum.reference, 'f', 2, 64)
+			cost = strconv.FormatFloat(sum.cost, 'f', 2, 64)
+			if sum.reference != 0 {
+				markup = strconv.FormatFloat(sum.cost/math.Abs(sum.reference)*100, 'f', 2, 64) + "%"
+			}
+		}
+		data = append(data,
+			[]string{
+				key.month,
+				key.currency,
+				strconv.Itoa(sum.count),
+				strconv.FormatFloat(sum.originalAmount, 'f', 2, 64),
+				strconv.FormatFloat(sum.amount, 'f', 2, 64),
+				reference,
+				cost,
+				markup,
+				strconv.Itoa(sum.missing),
+			},
+		)
+	}
+	return []string{"Month", "Currency", "Transactions", "Original Amount", "Amount", "Reference Amount", "Cost", "Markup", "Without Rate"}, data
+}
+
+// Group the payments with a known merchant country other than home by country.
+// Refunds are credits, not spending, and left out.
+func spendingAbroad(transactions *n26.Transactions, home n26.Country) ([]string, [][]string, float64) {
+	type countrySum struct {
+		count  int
+		amount float64
+	}
+	sums := map[n26.Country]*countrySum{}
+	var total float64
+	for _, transaction := range *transactions {
+		country := transaction.MerchantCountry
+		if country == 0 || country == home || transaction.Amount >= 0 {
+			continue
+		}
+		if sums[country] == nil {
+			sums[country] = &countrySum{}
+		}
+		sums[country].count++
+		sums[country].amount += transaction.Amount
+		total += transaction.Amount
+	}
+	countries := make([]n26.Country, 0, len(sums))
+	for country := range sums {
+		countries = append(countries, country)
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		return countries[i].String() < countries[j].String()
+	})
+	data := [][]string{}
+	for _, country := range countries {
+		data = append(data,
+			[]string{
+				country.String(),
+				country.Alpha2(),
+				strconv.Itoa(sums[country].count),
+				strconv.FormatFloat(sums[country].amount, 'f', 2, 64),
+			},
+		)
+	}
+	return []string{"Country", "Code", "Transactions", "Amount"}, data, total
+}
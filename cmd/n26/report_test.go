@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/guitmz/n26"
+)
+
+func TestSpendingAbroad(t *testing.T) {
+	de, _ := n26.CountryByAlpha2("DE")
+	fr, _ := n26.CountryByAlpha2("FR")
+	us, _ := n26.CountryByAlpha2("US")
+	transactions := n26.Transactions{
+		{Amount: -45.9, MerchantCountry: us},
+		{Amount: -10, MerchantCountry: fr},
+		{Amount: -2.5, MerchantCountry: fr},
+		// a refund is not spending
+		{Amount: 10, MerchantCountry: fr},
+		{Amount: -20, MerchantCountry: de},
+		{Amount: -5},
+	}
+	header, data, total := spendingAbroad(&transactions, de)
+	if want := []string{"Country", "Code", "Transactions", "Amount"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("got header %v, want %v", header, want)
+	}
+	want := [][]string{
+		{fr.String(), "FR", "2", "-12.50"},
+		{us.String(), "US", "1", "-45.90"},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("got %v, want %v", data, want)
+	}
+	if total != -58.4 {
+		t.Errorf("got total %v, want -58.4", total)
+	}
+}
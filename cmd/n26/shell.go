@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+	"golang.org/x/term"
+)
+
+// The client of the shell, used by all commands run in it
+var session *n26.Client
+
+// Raised by exit in the shell to end the command only
+type shellExit int
+
+// Raised by check in the shell when the access token was rejected
+type shellExpired struct {
+	err error
+}
+
+var shellCommand = cli.Command{
+	Name: "shell",
+	Usage: "interactive prompt running commands such as balance, transactions --since 7d or block ID, " +
+		"logging in only once. Global options given before shell apply to all commands",
+	Action: func(c *cli.Context) error {
+		if session != nil {
+			return cli.NewExitError("Already in the shell!", 1)
+		}
+		// logins again use the password of the password options read now
+		check(cachePassword())
+		API, err := authentication()
+		check(err)
+		session = API
+		globals := shellGlobals(c)
+		exit = func(code int) { panic(shellExit(code)) }
+		cli.OsExiter = exit
+
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			// commands piped in, one per line
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				if !runShellLine(c.App, globals, scanner.Text()) {
+					break
+				}
+			}
+			return scanner.Err()
+		}
+		terminal := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}, "n26> ")
+		completer := &shellCompleter{app: c.App, terminal: terminal}
+		terminal.AutoCompleteCallback = completer.complete
+		fmt.Println("Logged in. Type a command such as balance or transactions --since 7d, help for the list of " +
+			"commands and exit or ctrl-d to leave. Tab completes commands, flags and card IDs, ↑ and ↓ go through the history.")
+		for {
+			line, err := readShellLine(fd, terminal)
+			if err == io.EOF {
+				fmt.Println()
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !runShellLine(c.App, globals, line) {
+				return nil
+			}
+		}
+	},
+}
+
+// The global options given before the shell command, as arguments of the
+// commands run in it. The password options are left out, the shell reads
+// the password only once.
+func shellGlobals(c *cli.Context) []string {
+	globals := []string{}
+	for _, flag := range c.App.Flags {
+		name := strings.TrimSpace(strings.Split(flag.GetName(), ",")[0])
+		if !c.GlobalIsSet(name) || strings.HasPrefix(name, "password-") {
+			continue
+		}
+		switch flag.(type) {
+		case cli.BoolFlag:
+			if c.GlobalBool(name) {
+				globals = append(globals, "--"+name)
+			}
+		case cli.IntFlag:
+			globals = append(globals, fmt.Sprintf("--%s=%d", name, c.GlobalInt(name)))
+		default:
+			globals = append(globals, "--"+name+"="+c.GlobalString(name))
+		}
+	}
+	return globals
+}
+
+// Read a line with editing, history and completion in raw mode. Commands
+// run in the normal mode, to print and prompt as usual.
+func readShellLine(fd int, terminal *term.Terminal) (string, error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		terminal.SetSize(width, height)
+	}
+	return terminal.ReadLine()
+}
+
+// Run the command of the line, false to leave the shell. A command failing
+// as the access token expired runs again after logging in again.
+func runShellLine(app *cli.App, globals []string, line string) bool {
+	args, err := splitShellLine(line)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return true
+	}
+	if len(args) == 0 {
+		return true
+	}
+	if args[0] == "exit" || args[0] == "quit" {
+		return false
+	}
+	args = append(append([]string{os.Args[0]}, globals...), args...)
+	err = runShellCommand(app, args)
+	if n26.IsUnauthorized(err) {
+		fmt.Fprintln(os.Stderr, "[-] The access token expired, logging in again")
+		API, loginErr := login(currentProfile(), true)
+		if loginErr != nil {
+			fmt.Fprintln(os.Stderr, loginErr)
+			return true
+		}
+		session = API
+		err = runShellCommand(app, args)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return true
+}
+
+// Run the command, ending it instead of the shell on exit
+func runShellCommand(app *cli.App, args []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case shellExit:
+				err = nil
+			case shellExpired:
+				err = r.err
+			default:
+				panic(r)
+			}
+		}
+	}()
+	return app.Run(args)
+}
+
+// Split the line into words at spaces, which may be quoted with single or
+// double quotes or escaped with a backslash
+func splitShellLine(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// Completes the commands, subcommands, flags, output formats and card IDs
+// of shell lines
+type shellCompleter struct {
+	app      *cli.App
+	terminal *term.Terminal
+	// retrieved on the first completion of a card ID
+	cardIDs []string
+}
+
+func (s *shellCompleter) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	head := line[:pos]
+	start := strings.LastIndexAny(head, " \t") + 1
+	word := head[start:]
+	matches := []string{}
+	for _, candidate := range s.candidates(strings.Fields(head[:start]), word) {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+	sort.Strings(matches)
+	completion := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, completion) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+	if len(matches) == 1 {
+		completion += " "
+	}
+	if completion == word {
+		// nothing to add, show the choices
+		fmt.Fprintln(s.terminal, strings.Join(matches, "  "))
+		return "", 0, false
+	}
+	return head[:start] + completion + line[pos:], start + len(completion), true
+}
+
+var argsChoices = regexp.MustCompile(`\[([a-z0-9]+(?:\|[a-z0-9]+)+)`)
+
+// The words that can follow the words before it
+func (s *shellCompleter) candidates(before []string, word string) []string {
+	var command *cli.Command
+	commands := s.app.Commands
+	for _, w := range before {
+		for i := range commands {
+			if commands[i].HasName(w) {
+				command, commands = &commands[i], commands[i].Subcommands
+				break
+			}
+		}
+	}
+	candidates := []string{}
+	if strings.HasPrefix(word, "-") {
+		flags := s.app.Flags
+		if command != nil {
+			flags = command.Flags
+		}
+		for _, f := range flags {
+			for _, name := range strings.Split(f.GetName(), ",") {
+				if name = strings.TrimSpace(name); len(name) > 1 {
+					candidates = append(candidates, "--"+name)
+				}
+			}
+		}
+		return candidates
+	}
+	if command == nil {
+		for _, c := range s.app.Commands {
+			if !c.Hidden {
+				candidates = append(candidates, c.Names()...)
+			}
+		}
+		return append(candidates, "exit", "quit")
+	}
+	for _, c := range command.Subcommands {
+		candidates = append(candidates, c.Names()...)
+	}
+	if match := argsChoices.FindStringSubmatch(command.ArgsUsage); match != nil {
+		candidates = append(candidates, strings.Split(match[1], "|")...)
+	}
+	if command.Name == "block" || command.Name == "unblock" {
+		candidates = append(candidates, s.cards()...)
+	}
+	return candidates
+}
+
+func (s *shellCompleter) cards() []string {
+	if s.cardIDs == nil && session != nil {
+		s.cardIDs = []string{}
+		if cards, err := session.GetCards(); err == nil {
+			for _, card := range *cards {
+				s.cardIDs = append(s.cardIDs, card.ID)
+			}
+		}
+	}
+	return s.cardIDs
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+func TestSplitShellLine(t *testing.T) {
+	cases := []struct {
+		line  string
+		words []string
+		err   bool
+	}{
+		{"", []string{}, false},
+		{"  balance  ", []string{"balance"}, false},
+		{"transactions --since 7d", []string{"transactions", "--since", "7d"}, false},
+		{`transactions --search 'Pizza Place'`, []string{"transactions", "--search", "Pizza Place"}, false},
+		{`transactions --search "it's" ''`, []string{"transactions", "--search", "it's", ""}, false},
+		{`transactions --search Pizza\ Place`, []string{"transactions", "--search", "Pizza Place"}, false},
+		{`transactions --search 'Pizza`, nil, true},
+		{`balance \`, nil, true},
+	}
+	for _, c := range cases {
+		words, err := splitShellLine(c.line)
+		if (err != nil) != c.err || !reflect.DeepEqual(words, c.words) {
+			t.Errorf("%s: got %q, %v, want %q, error %v", c.line, words, err, c.words, c.err)
+		}
+	}
+}
+
+func TestRunShellCommand(t *testing.T) {
+	defer func(e func(int)) { exit, session = e, nil }(exit)
+	exit = func(code int) { panic(shellExit(code)) }
+	session = &n26.Client{}
+	unauthorized := &n26.StatusError{StatusCode: 401, Status: "401 Unauthorized"}
+	cases := []struct {
+		name   string
+		action func(c *cli.Context) error
+		err    error
+	}{
+		{"success", func(c *cli.Context) error { return nil }, nil},
+		{"exit", func(c *cli.Context) error { check(errors.New("failed")); return nil }, nil},
+		{"expired", func(c *cli.Context) error { check(unauthorized); return nil }, unauthorized},
+		{"returned", func(c *cli.Context) error { return unauthorized }, unauthorized},
+	}
+	for _, c := range cases {
+		app := cli.NewApp()
+		app.Action = c.action
+		if err := runShellCommand(app, []string{"n26"}); err != c.err {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.err)
+		}
+	}
+}
+
+func TestShellGlobals(t *testing.T) {
+	globals := []string{"-o", "json", "--password-file", "secret", "--no-header", "--sort", "-amount", "--profile", "work"}
+	runWithGlobals(t, globals, nil, []string{"balance"}, func(c *cli.Context) {
+		want := []string{"--profile=work", "--output=json", "--sort=-amount", "--no-header"}
+		if got := shellGlobals(c); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+	runWithGlobals(t, nil, nil, nil, func(c *cli.Context) {
+		if got := shellGlobals(c); len(got) != 0 {
+			t.Errorf("got %q, want none", got)
+		}
+	})
+}
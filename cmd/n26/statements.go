@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+func statementListFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.IntFlag{Name: "year", Usage: "only statements of the year"},
+		cli.StringFlag{Name: "from", Usage: "only statements from this month on, in the format yyyy-mm. E.g. 2018-03"},
+		cli.StringFlag{Name: "to", Usage: "only statements until this month, in the format yyyy-mm. E.g. 2018-12"},
+		cli.BoolFlag{Name: "latest", Usage: "download the most recent statement, skipped if already present"},
+	}
+}
+
+// A month as a number for comparison
+func statementMonth(year, month int) int {
+	return year*12 + month - 1
+}
+
+func parseStatementMonth(flag, value string) (int, error) {
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s month %q, expected yyyy-mm", flag, value)
+	}
+	return statementMonth(month.Year(), int(month.Month())), nil
+}
+
+// The statements selected by --year, --from and --to, oldest first
+func filterStatements(c *cli.Context, statements n26.Statements) (n26.Statements, error) {
+	from, to := 0, statementMonth(9999, 12)
+	var err error
+	if c.String("from") != "" {
+		if from, err = parseStatementMonth("from", c.String("from")); err != nil {
+			return nil, err
+		}
+	}
+	if c.String("to") != "" {
+		if to, err = parseStatementMonth("to", c.String("to")); err != nil {
+			return nil, err
+		}
+	}
+	if to < from {
+		return nil, fmt.Errorf("--to %s is before --from %s", c.String("to"), c.String("from"))
+	}
+	selected := n26.Statements{}
+	for _, statement := range statements {
+		month := statementMonth(statement.Year, statement.Month)
+		if (c.Int("year") == 0 || statement.Year == c.Int("year")) && month >= from && month <= to {
+			selected = append(selected, statement)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return statementMonth(selected[i].Year, selected[i].Month) < statementMonth(selected[j].Year, selected[j].Month)
+	})
+	return selected, nil
+}
+
+// The time the statement became available
+func statementAvailable(visibleTS int64) time.Time {
+	return n26.TimeStampFromMillis(visibleTS).Time
+}
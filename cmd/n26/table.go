@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"regexp"
 
 	"github.com/olekukonko/tablewriter"
 )
@@ -14,9 +15,46 @@ func NewTableWriter() *tblWriter {
 	return &tblWriter{tablewriter.NewWriter(os.Stdout)}
 }
 
+var (
+	// plain or locale formatted amounts and numbers, e.g. -1.234,56 €, €1,234.56 or 1.5%
+	amountCell = regexp.MustCompile(`^[-+]?(?:[€$£¥][\x{a0} ]?|[A-Z]{3}[\x{a0} ])?\d[\d.,'’\x{a0}\x{202f}]*(?:[\x{a0} ]?(?:[€$£¥%]|[A-Z]{3}))?$`)
+	colorCode  = regexp.MustCompile("\033\\[[0-9;]*m")
+)
+
 func (table *tblWriter) WriteData(header []string, data [][]string) error {
-	table.SetHeader(header)
+	if header != nil {
+		table.SetHeader(header)
+	}
+	table.SetColumnAlignment(columnAlignment(data))
 	table.AppendBulk(data)
 	table.Render()
 	return nil
 }
+
+// Right align columns of amounts and numbers
+func columnAlignment(data [][]string) []int {
+	columns := 0
+	for _, row := range data {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	alignment := make([]int, columns)
+	for i := range alignment {
+		numeric, empty := true, true
+		for _, row := range data {
+			if i >= len(row) || row[i] == "" {
+				continue
+			}
+			empty = false
+			if !amountCell.MatchString(colorCode.ReplaceAllString(row[i], "")) {
+				numeric = false
+				break
+			}
+		}
+		if numeric && !empty {
+			alignment[i] = tablewriter.ALIGN_RIGHT
+		}
+	}
+	return alignment
+}
@@ -0,0 +1,792 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/guitmz/n26"
+	"github.com/mattn/go-runewidth"
+	"github.com/urfave/cli"
+	"golang.org/x/term"
+)
+
+var tuiCommand = cli.Command{
+	Name: "tui",
+	Usage: "full-screen terminal interface with your balance, spaces, cards and a searchable list of your " +
+		"transactions, refreshed periodically",
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "limit", Value: "200", Usage: "number of recent transactions to show"},
+		cli.DurationFlag{Name: "refresh", Value: time.Minute, Usage: "interval of the live refresh, 0 to refresh with r only"},
+		ratesFlag,
+	},
+	Action: func(c *cli.Context) error {
+		if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			return cli.NewExitError("The terminal UI needs a terminal!", 1)
+		}
+		rates, err := readRatesFlag(c)
+		check(err)
+		API, err := authentication()
+		check(err)
+		ui := &tui{API: API, limit: c.String("limit"), rates: rates, color: colorOutput()}
+		return ui.run(c.Duration("refresh"))
+	},
+}
+
+// The data shown by the terminal UI, retrieved at once
+type tuiData struct {
+	balance      *n26.Balance
+	spaces       *n26.Spaces
+	cards        *n26.Cards
+	transactions n26.Transactions
+	err          error
+	// the client logged in again with, if the access token expired
+	API *n26.Client
+}
+
+// Retrieve the data, logging in again if the access token expired
+func refetchTuiData(API *n26.Client, limit string) tuiData {
+	d := fetchTuiData(API, limit)
+	if !n26.IsUnauthorized(d.err) {
+		return d
+	}
+	if API, d.err = login(currentProfile(), true); d.err != nil {
+		return d
+	}
+	d = fetchTuiData(API, limit)
+	d.API = API
+	return d
+}
+
+func fetchTuiData(API *n26.Client, limit string) (d tuiData) {
+	if d.balance, d.err = API.GetBalance(); d.err != nil {
+		return
+	}
+	if d.spaces, d.err = API.GetSpaces(); d.err != nil {
+		return
+	}
+	if d.cards, d.err = API.GetCards(); d.err != nil {
+		return
+	}
+	transactions, err := API.GetLastTransactions(limit)
+	if d.err = err; err == nil {
+		d.transactions = *transactions
+	}
+	return
+}
+
+// The pane with the keyboard focus
+const (
+	focusTransactions = iota
+	focusCards
+)
+
+// What the event loop does after a key
+type tuiAction int
+
+const (
+	tuiNone tuiAction = iota
+	tuiRefresh
+	tuiQuit
+	// run the confirmed action
+	tuiRun
+)
+
+// A question answered with y before the action is taken
+type tuiConfirm struct {
+	question string
+	action   func() error
+	// the status while the action runs, and after it succeeded
+	running, done string
+}
+
+// The state of the terminal UI
+type tui struct {
+	API   *n26.Client
+	limit string
+	rates *n26.ExchangeRates
+	color bool
+
+	data       tuiData
+	loaded     bool
+	refreshed  time.Time
+	refreshing bool
+
+	focus int
+	// selected transaction of the visible ones, and the first one shown
+	cursor, offset int
+	card           int
+	// whether the selected transaction is shown, and its first line shown
+	detail       bool
+	detailOffset int
+	// search text, and whether it is being typed
+	search    string
+	searching bool
+	confirm   *tuiConfirm
+	// the confirmed action, while it runs
+	running *tuiConfirm
+	status  string
+
+	width, height int
+}
+
+func (ui *tui) run(interval time.Duration) error {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+	// alternate screen without cursor, restored on exit
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+	fetched := make(chan tuiData, 1)
+	refresh := func() {
+		if !ui.refreshing {
+			ui.refreshing = true
+			API := ui.API
+			go func() { fetched <- refetchTuiData(API, ui.limit) }()
+		}
+	}
+	// the API calls of confirmed actions don't block the UI either
+	done := make(chan error, 1)
+	refresh()
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	// the size is polled as there is no portable resize signal
+	resize := time.NewTicker(250 * time.Millisecond)
+	defer resize.Stop()
+
+	ui.width, ui.height, _ = term.GetSize(fd)
+	for redraw := true; ; {
+		if redraw {
+			ui.draw(os.Stdout)
+		}
+		redraw = true
+		select {
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch ui.key(k) {
+			case tuiQuit:
+				return nil
+			case tuiRefresh:
+				refresh()
+			case tuiRun:
+				go func(action func() error) { done <- action() }(ui.running.action)
+			}
+		case err := <-done:
+			if ui.finished(err) == tuiRefresh {
+				refresh()
+			}
+		case d := <-fetched:
+			ui.refreshing = false
+			ui.update(d)
+		case <-tick:
+			refresh()
+		case <-resize.C:
+			width, height, err := term.GetSize(fd)
+			if redraw = err == nil && (width != ui.width || height != ui.height); redraw {
+				ui.width, ui.height = width, height
+				fmt.Print("\033[2J")
+			}
+		}
+	}
+}
+
+// Show the retrieved data, keeping the selected transaction and card
+func (ui *tui) update(d tuiData) {
+	if d.API != nil {
+		ui.API = d.API
+	}
+	if d.err != nil {
+		ui.status = "Refresh failed: " + d.err.Error()
+		return
+	}
+	var selected string
+	if visible := ui.visible(); ui.cursor < len(visible) {
+		selected = visible[ui.cursor].ID
+	}
+	ui.data, ui.loaded, ui.refreshed = d, true, time.Now()
+	for i, t := range ui.visible() {
+		if t.ID == selected {
+			ui.cursor = i
+		}
+	}
+	ui.cursor = clamp(ui.cursor, 0, len(ui.visible())-1)
+	ui.card = clamp(ui.card, 0, ui.cardCount()-1)
+}
+
+// The transactions matching the search
+func (ui *tui) visible() n26.Transactions {
+	if ui.search == "" {
+		return ui.data.transactions
+	}
+	search := strings.ToLower(ui.search)
+	visible := n26.Transactions{}
+	for _, t := range ui.data.transactions {
+		for _, text := range []string{t.MerchantName, t.PartnerName, t.ReferenceText, t.CategoryName(), t.PartnerIban,
+			t.MerchantCity, t.Type, strconv.FormatFloat(t.Amount, 'f', 2, 64)} {
+			if strings.Contains(strings.ToLower(text), search) {
+				visible = append(visible, t)
+				break
+			}
+		}
+	}
+	return visible
+}
+
+func (ui *tui) cardCount() int {
+	if ui.data.cards == nil {
+		return 0
+	}
+	return len(*ui.data.cards)
+}
+
+// Handle a key, see readKeys for the names of special keys
+func (ui *tui) key(k string) tuiAction {
+	ui.status = ""
+	if k == "ctrl-c" {
+		return tuiQuit
+	}
+	if confirm := ui.confirm; confirm != nil {
+		ui.confirm = nil
+		if k != "y" && k != "Y" {
+			ui.status = "Cancelled"
+			return tuiNone
+		}
+		ui.running = confirm
+		return tuiRun
+	}
+	if ui.searching {
+		switch k {
+		case "enter":
+			ui.searching = false
+		case "esc":
+			ui.searching, ui.search = false, ""
+		case "backspace":
+			if _, size := utf8.DecodeLastRuneInString(ui.search); size > 0 {
+				ui.search = ui.search[:len(ui.search)-size]
+			}
+		default:
+			if r, size := utf8.DecodeRuneInString(k); size == len(k) && unicode.IsPrint(r) {
+				ui.search += k
+			}
+		}
+		ui.cursor, ui.offset = 0, 0
+		return tuiNone
+	}
+	page := ui.height - 4
+	switch k {
+	case "q":
+		if !ui.detail {
+			return tuiQuit
+		}
+		ui.detail = false
+	case "esc", "backspace":
+		if ui.detail {
+			ui.detail = false
+		} else if ui.search != "" {
+			ui.search, ui.cursor, ui.offset = "", 0, 0
+		}
+	case "r", "ctrl-l":
+		ui.status = "Refreshing"
+		return tuiRefresh
+	case "tab", "backtab":
+		ui.focus = 1 - ui.focus
+		ui.detail = false
+	case "/":
+		ui.focus, ui.detail, ui.searching = focusTransactions, false, true
+	case "up", "k":
+		ui.move(-1)
+	case "down", "j":
+		ui.move(1)
+	case "pgup":
+		if ui.detail {
+			ui.detailOffset = clamp(ui.detailOffset-page, 0, ui.detailOffset)
+		} else {
+			ui.move(-page)
+		}
+	case "pgdn", " ":
+		if ui.detail {
+			ui.detailOffset += page
+		} else {
+			ui.move(page)
+		}
+	case "home", "g":
+		ui.move(-len(ui.data.transactions))
+	case "end", "G":
+		ui.move(len(ui.data.transactions))
+	case "enter":
+		if ui.focus == focusTransactions && len(ui.visible()) > 0 {
+			ui.detail, ui.detailOffset = !ui.detail, 0
+		}
+	case "b", "u":
+		if ui.focus != focusCards || ui.cardCount() == 0 {
+			ui.status = "Select a card with tab first"
+			break
+		}
+		if ui.running != nil {
+			ui.status = ui.running.running
+			break
+		}
+		card := (*ui.data.cards)[ui.card]
+		ID, name, API := card.ID, strings.TrimSpace(card.CardType+" "+card.MaskedPan), ui.API
+		if k == "b" {
+			ui.confirm = &tuiConfirm{fmt.Sprintf("Block card %s? [y/N]", name), func() error { return API.BlockCard(ID) },
+				fmt.Sprintf("Blocking card %s", name), fmt.Sprintf("Card %s blocked", name)}
+		} else {
+			ui.confirm = &tuiConfirm{fmt.Sprintf("Unblock card %s? [y/N]", name), func() error { return API.UnblockCard(ID) },
+				fmt.Sprintf("Unblocking card %s", name), fmt.Sprintf("Card %s unblocked", name)}
+		}
+	}
+	return tuiNone
+}
+
+// Show the result of the confirmed action that ran
+func (ui *tui) finished(err error) tuiAction {
+	confirm := ui.running
+	ui.running = nil
+	if err != nil {
+		ui.status = "Failed: " + err.Error()
+		return tuiNone
+	}
+	ui.status = confirm.done
+	return tuiRefresh
+}
+
+// Move the selection of the focused pane
+func (ui *tui) move(by int) {
+	ui.detailOffset = 0
+	if ui.focus == focusCards {
+		ui.card = clamp(ui.card+by, 0, ui.cardCount()-1)
+		return
+	}
+	ui.cursor = clamp(ui.cursor+by, 0, len(ui.visible())-1)
+}
+
+func clamp(i, min, max int) int {
+	if i > max {
+		i = max
+	}
+	if i < min {
+		i = min
+	}
+	return i
+}
+
+// Draw the whole screen, overwriting the previous one
+func (ui *tui) draw(w io.Writer) {
+	screen := &strings.Builder{}
+	screen.WriteString("\033[H")
+	for i, line := range ui.render(ui.width, ui.height) {
+		if i > 0 {
+			screen.WriteString("\r\n")
+		}
+		screen.WriteString(line)
+		screen.WriteString("\033[K")
+	}
+	io.WriteString(w, screen.String())
+}
+
+const (
+	styleInverse = "\033[7m"
+	styleBold    = "\033[1m"
+	styleReset   = "\033[0m"
+)
+
+// The lines of the screen: a title bar, the balance, spaces and cards on the
+// left, the transactions or the selected one on the right, and a status line
+func (ui *tui) render(width, height int) []string {
+	if width < 20 || height < 5 {
+		return []string{fit("N26", width)}
+	}
+	title := " N26"
+	if activeProfile != nil {
+		title += " · " + activeProfile.name
+	}
+	refreshed := "Loading "
+	if ui.refreshing && ui.loaded {
+		refreshed = "Refreshing "
+	} else if ui.loaded {
+		refreshed = "Refreshed " + ui.refreshed.Format("15:04:05") + " "
+	}
+	lines := []string{styleInverse + fit(title, width-runewidth.StringWidth(refreshed)) + refreshed + styleReset}
+
+	body := height - 2
+	left := width / 3
+	if left > 40 {
+		left = 40
+	}
+	right := width - left - 1
+	accounts := ui.renderAccounts(left, body)
+	var transactions []string
+	if ui.detail {
+		transactions = ui.renderDetail(right, body)
+	} else {
+		transactions = ui.renderTransactions(right, body)
+	}
+	for i := 0; i < body; i++ {
+		lines = append(lines, accounts[i]+"│"+transactions[i])
+	}
+	return append(lines, ui.renderStatus(width))
+}
+
+// The balance, spaces and cards pane
+func (ui *tui) renderAccounts(width, height int) []string {
+	lines := []string{}
+	row := func(name string, amount float64) string {
+		text := ui.money(amount, "EUR")
+		return " " + fit(name, width-runewidth.StringWidth(text)-2) + ui.colorAmount(amount, text) + " "
+	}
+	heading := func(name string) string {
+		return styleBold + fit(" "+name, width) + styleReset
+	}
+	if b := ui.data.balance; b != nil {
+		lines = append(lines, heading("Balance"), row("Available", b.AvailableBalance), row("Usable", b.UsableBalance),
+			fit(" "+b.IBAN, width), fit("", width))
+	}
+	if s := ui.data.spaces; s != nil {
+		lines = append(lines, heading("Spaces"))
+		for _, space := range s.Spaces {
+			lines = append(lines, row(space.Name, space.Balance.AvailableBalance))
+		}
+		lines = append(lines, row("Total", s.TotalBalance), fit("", width))
+	}
+	if cards := ui.data.cards; cards != nil {
+		lines = append(lines, heading("Cards"))
+		for i, card := range *cards {
+			line := fit(fmt.Sprintf(" %s %s %s", card.CardType, card.MaskedPan, strings.TrimPrefix(card.Status, "M_")), width)
+			if ui.focus == focusCards && i == ui.card {
+				line = styleInverse + line + styleReset
+			}
+			lines = append(lines, line)
+		}
+	}
+	return pad(lines, width, height)
+}
+
+// The list of transactions, newest first
+func (ui *tui) renderTransactions(width, height int) []string {
+	visible := ui.visible()
+	title := fmt.Sprintf(" Transactions (%d)", len(visible))
+	if ui.search != "" {
+		title = fmt.Sprintf(" Transactions matching %q (%d of %d)", ui.search, len(visible), len(ui.data.transactions))
+	}
+	lines := []string{styleBold + fit(title, width) + styleReset}
+	rows := height - 1
+	if ui.cursor < ui.offset {
+		ui.offset = ui.cursor
+	}
+	if ui.cursor >= ui.offset+rows {
+		ui.offset = ui.cursor - rows + 1
+	}
+	shown := visible[ui.offset:clamp(ui.offset+rows, 0, len(visible))]
+	// amounts right aligned in a column as wide as the widest shown
+	amountWidth := 0
+	for _, t := range shown {
+		if w := runewidth.StringWidth(ui.money(t.Amount, t.CurrencyCode)); w > amountWidth {
+			amountWidth = w
+		}
+	}
+	for j, t := range shown {
+		i := ui.offset + j
+		date := ui.date(t.VisibleTS.Time)
+		amount := runewidth.FillLeft(ui.money(t.Amount, t.CurrencyCode), amountWidth)
+		name := transactionName(t)
+		if t.Pending {
+			name = "* " + name
+		}
+		nameWidth := width - runewidth.StringWidth(date) - amountWidth - 4
+		if ui.focus == focusTransactions && i == ui.cursor {
+			lines = append(lines, styleInverse+" "+date+" "+fit(name, nameWidth)+" "+amount+" "+styleReset)
+			continue
+		}
+		lines = append(lines, " "+date+" "+fit(name, nameWidth)+" "+ui.colorAmount(t.Amount, amount)+" ")
+	}
+	if !ui.loaded {
+		lines = append(lines, fit(" Waiting for the login approval and the data", width))
+	}
+	return pad(lines, width, height)
+}
+
+// The name of the other party of the transaction, or its category
+func transactionName(t n26.Transaction) string {
+	for _, name := range []string{t.MerchantName, t.PartnerName, t.ReferenceText} {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	return t.CategoryName()
+}
+
+// All fields of the selected transaction, and its conversion for foreign currencies
+func (ui *tui) renderDetail(width, height int) []string {
+	visible := ui.visible()
+	if ui.cursor >= len(visible) {
+		return pad(nil, width, height)
+	}
+	t := visible[ui.cursor]
+	lines := []string{styleBold + fit(" "+transactionName(t), width) + styleReset, fit("", width)}
+	fields := [][2]string{
+		{"Amount", ui.money(t.Amount, t.CurrencyCode)},
+		{"Time", ui.dateTime(t.VisibleTS.Time)},
+		{"Category", t.CategoryName()},
+		{"Reference", t.ReferenceText},
+	}
+	if t.IsForeignCurrency() {
+		fields = append(fields, [2]string{"Original Amount", ui.money(t.OriginalAmount, t.OriginalCurrency)},
+			[2]string{"Effective Rate", strconv.FormatFloat(t.EffectiveRate(), 'f', 4, 64)})
+		if reference, ok := ui.rates.Rate(t.OriginalCurrency, t.VisibleTS.Time); ok {
+			cost, markup := t.ConversionCost(reference)
+			fields = append(fields, [2]string{"Reference Rate", strconv.FormatFloat(reference, 'f', 4, 64)},
+				[2]string{"Conversion Cost", fmt.Sprintf("%s (%.2f%%)", ui.money(cost, t.CurrencyCode), markup*100)})
+		}
+	}
+	value := reflect.ValueOf(t)
+	for i := 0; i < value.NumField(); i++ {
+		text := formatCell(value.Field(i).Interface())
+		if stamp, ok := value.Field(i).Interface().(n26.TimeStamp); ok {
+			text = ui.dateTime(stamp.Time)
+		}
+		fields = append(fields, [2]string{value.Type().Field(i).Name, text})
+	}
+	labelWidth := 0
+	for _, f := range fields {
+		if w := runewidth.StringWidth(f[0]); w > labelWidth {
+			labelWidth = w
+		}
+	}
+	valueWidth := width - labelWidth - 4
+	for _, f := range fields {
+		label := " " + runewidth.FillRight(f[0], labelWidth) + "  "
+		// long values such as the reference text wrap
+		for j, part := range wrap(printable(f[1]), valueWidth) {
+			if j > 0 {
+				label = strings.Repeat(" ", labelWidth+3)
+			}
+			lines = append(lines, label+fit(part, valueWidth)+" ")
+		}
+	}
+	// the name stays on top when scrolling
+	ui.detailOffset = clamp(ui.detailOffset, 0, len(lines)-height)
+	lines = append(lines[:2], lines[2+ui.detailOffset:]...)
+	return pad(lines, width, height)
+}
+
+// The status line: the search being typed, a question, a message or the keys
+func (ui *tui) renderStatus(width int) string {
+	switch {
+	case ui.searching:
+		return styleInverse + fit(" Search: "+ui.search+"▏", width) + styleReset
+	case ui.confirm != nil:
+		return styleInverse + fit(" "+ui.confirm.question, width) + styleReset
+	case ui.status != "":
+		return styleInverse + fit(" "+ui.status, width) + styleReset
+	case ui.running != nil:
+		return styleInverse + fit(" "+ui.running.running, width) + styleReset
+	case ui.detail:
+		return styleInverse + fit(" ↑↓ previous/next  pgup/pgdn scroll  esc back", width) + styleReset
+	case ui.focus == focusCards:
+		return styleInverse + fit(" ↑↓ select  b block  u unblock  tab transactions  r refresh  q quit", width) + styleReset
+	}
+	return styleInverse + fit(" ↑↓ select  enter details  / search  tab cards  r refresh  q quit", width) + styleReset
+}
+
+func (ui *tui) money(amount float64, currency string) string {
+	if displayLocale != nil {
+		return displayLocale.money(money{amount, currency})
+	}
+	return strings.TrimSpace(strconv.FormatFloat(amount, 'f', minorUnits(currency), 64) + " " + currency)
+}
+
+func (ui *tui) date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if displayLocale != nil {
+		return t.In(n26.DisplayLocation).Format(displayLocale.date)
+	}
+	return t.In(n26.DisplayLocation).Format("2006-01-02")
+}
+
+func (ui *tui) dateTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if displayLocale != nil {
+		return t.In(n26.DisplayLocation).Format(displayLocale.dateTime)
+	}
+	return t.In(n26.DisplayLocation).Format("2006-01-02 15:04:05")
+}
+
+func (ui *tui) colorAmount(amount float64, text string) string {
+	if ui.color {
+		return colorAmount(amount, text)
+	}
+	return text
+}
+
+// Pad or cut the text to the width, replacing control characters
+func fit(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return runewidth.FillRight(runewidth.Truncate(printable(text), width, "…"), width)
+}
+
+func printable(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// Break the text into lines of the width between words, and within words
+// longer than the width. Runes wider than the width get a line of their own.
+func wrap(text string, width int) []string {
+	lines := []string{""}
+	for _, word := range strings.Fields(text) {
+		last := len(lines) - 1
+		switch {
+		case lines[last] == "":
+			lines[last] = word
+		case runewidth.StringWidth(lines[last])+1+runewidth.StringWidth(word) <= width:
+			lines[last] += " " + word
+		default:
+			lines = append(lines, word)
+		}
+		for last = len(lines) - 1; width > 0 && runewidth.StringWidth(lines[last]) > width &&
+			utf8.RuneCountInString(lines[last]) > 1; last++ {
+			head := runewidth.Truncate(lines[last], width, "")
+			if head == "" {
+				// at least one rune per line, even one wider than the line
+				_, size := utf8.DecodeRuneInString(lines[last])
+				head = lines[last][:size]
+			}
+			lines = append(lines[:last], head, lines[last][len(head):])
+		}
+	}
+	return lines
+}
+
+// Fill the lines of a pane to its height with blank lines
+func pad(lines []string, width, height int) []string {
+	if len(lines) > height {
+		return lines[:height]
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return lines
+}
+
+// The escape sequences of special keys
+var keySequences = []struct {
+	sequence, name string
+}{
+	{"\033[A", "up"}, {"\033[B", "down"}, {"\033[C", "right"}, {"\033[D", "left"},
+	{"\033OA", "up"}, {"\033OB", "down"}, {"\033OC", "right"}, {"\033OD", "left"},
+	{"\033[5~", "pgup"}, {"\033[6~", "pgdn"},
+	{"\033[H", "home"}, {"\033[F", "end"}, {"\033OH", "home"}, {"\033OF", "end"},
+	{"\033[1~", "home"}, {"\033[4~", "end"}, {"\033[7~", "home"}, {"\033[8~", "end"},
+	{"\033[Z", "backtab"},
+}
+
+// Send the keys typed on the terminal in raw mode: the names of special
+// keys, e.g. up, enter or ctrl-c, and the typed characters. A character a
+// read cut is completed by the next one.
+func readKeys(r io.Reader, keys chan<- string) {
+	defer close(keys)
+	buffer := make([]byte, 256)
+	kept := 0
+	for {
+		n, err := r.Read(buffer[kept:])
+		if err != nil {
+			return
+		}
+		input := buffer[:kept+n]
+		complete := len(input) - incompleteRune(input)
+		for _, k := range parseKeys(input[:complete]) {
+			keys <- k
+		}
+		kept = copy(buffer, input[complete:])
+	}
+}
+
+// The length of the incomplete UTF-8 encoding of a rune ending the input
+func incompleteRune(input []byte) int {
+	for i := 1; i < utf8.UTFMax && i <= len(input); i++ {
+		if start := input[len(input)-i:]; utf8.RuneStart(start[0]) {
+			if utf8.FullRune(start) {
+				return 0
+			}
+			return i
+		}
+	}
+	return 0
+}
+
+func parseKeys(input []byte) []string {
+	keys := []string{}
+	for len(input) > 0 {
+		if input[0] == '\033' {
+			known := false
+			for _, k := range keySequences {
+				if bytes.HasPrefix(input, []byte(k.sequence)) {
+					keys, input, known = append(keys, k.name), input[len(k.sequence):], true
+					break
+				}
+			}
+			if known {
+				continue
+			}
+			if len(input) > 1 && (input[1] == '[' || input[1] == 'O') {
+				// skip unknown sequences up to their final byte
+				end := 2
+				for end < len(input) && (input[end] < 0x40 || input[end] > 0x7e) {
+					end++
+				}
+				input = input[clamp(end+1, 0, len(input)):]
+				continue
+			}
+			keys, input = append(keys, "esc"), input[1:]
+			continue
+		}
+		switch input[0] {
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case '\t':
+			keys = append(keys, "tab")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		case 0x0c:
+			keys = append(keys, "ctrl-l")
+		default:
+			r, size := utf8.DecodeRune(input)
+			keys, input = append(keys, string(r)), input[size:]
+			continue
+		}
+		input = input[1:]
+	}
+	return keys
+}
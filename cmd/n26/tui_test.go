@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/guitmz/n26"
+)
+
+func TestWrap(t *testing.T) {
+	cases := []struct {
+		text  string
+		width int
+		lines []string
+	}{
+		{"", 10, []string{""}},
+		{"Pizza Place Berlin", 11, []string{"Pizza Place", "Berlin"}},
+		{"  Pizza   Place  ", 20, []string{"Pizza Place"}},
+		{"IBAN DE89370400440532013000", 10, []string{"IBAN", "DE89370400", "4405320130", "00"}},
+		{"日本語", 3, []string{"日", "本", "語"}},
+		{"Pizza 😀", 1, []string{"P", "i", "z", "z", "a", "😀"}},
+		{"😀😀 a", 1, []string{"😀", "😀", "a"}},
+		{"a b", 0, []string{"a", "b"}},
+	}
+	for _, c := range cases {
+		if lines := wrap(c.text, c.width); !reflect.DeepEqual(lines, c.lines) {
+			t.Errorf("%q, %d: got %q, want %q", c.text, c.width, lines, c.lines)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	cases := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"abc", 5, "abc  "},
+		{"abc", 3, "abc"},
+		{"abcdef", 4, "abc…"},
+		{"a\tb\x1b[2J", 8, "a b [2J "},
+		{"日本語", 4, "日… "},
+		{"abc", 0, ""},
+		{"abc", -1, ""},
+	}
+	for _, c := range cases {
+		if got := fit(c.text, c.width); got != c.want {
+			t.Errorf("%q, %d: got %q, want %q", c.text, c.width, got, c.want)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	cases := []struct {
+		input string
+		keys  []string
+	}{
+		{"", []string{}},
+		{"q", []string{"q"}},
+		{"é/ü", []string{"é", "/", "ü"}},
+		{"\033[A\033[B\033OC\033OD", []string{"up", "down", "right", "left"}},
+		{"\033[5~\033[6~\033[H\033[4~", []string{"pgup", "pgdn", "home", "end"}},
+		{"\r\n\t\033[Z", []string{"enter", "enter", "tab", "backtab"}},
+		{"\x7f\x08\x03\x0c", []string{"backspace", "backspace", "ctrl-c", "ctrl-l"}},
+		{"\033", []string{"esc"}},
+		{"\033q", []string{"esc", "q"}},
+		// unknown sequences are skipped
+		{"\033[1;5Aj", []string{"j"}},
+		{"\033[15~k", []string{"k"}},
+	}
+	for _, c := range cases {
+		if keys := parseKeys([]byte(c.input)); !reflect.DeepEqual(keys, c.keys) {
+			t.Errorf("%q: got %q, want %q", c.input, keys, c.keys)
+		}
+	}
+}
+
+func TestTuiConfirm(t *testing.T) {
+	cases := []struct {
+		key    string
+		err    error
+		action tuiAction
+		status string
+	}{
+		{"y", nil, tuiRefresh, "Card blocked"},
+		{"y", errors.New("N26 API responded 403 Forbidden"), tuiNone, "Failed: N26 API responded 403 Forbidden"},
+		{"n", nil, tuiNone, "Cancelled"},
+	}
+	for _, c := range cases {
+		called := false
+		ui := &tui{API: &n26.Client{}}
+		ui.confirm = &tuiConfirm{"Block card?", func() error { called = true; return c.err }, "Blocking card", "Card blocked"}
+		action := ui.key(c.key)
+		// the event loop runs the action, not the key
+		if called || ui.confirm != nil || (action == tuiRun) != (c.key == "y") {
+			t.Errorf("%s: got %v, called %v", c.key, action, called)
+		}
+		if action == tuiRun {
+			if ui.renderStatus(20) != styleInverse+fit(" Blocking card", 20)+styleReset {
+				t.Errorf("%s: got status %q while running", c.key, ui.renderStatus(20))
+			}
+			action = ui.finished(ui.running.action())
+		}
+		if action != c.action || ui.status != c.status || ui.running != nil || called != (c.key == "y") {
+			t.Errorf("%s, %v: got %v, %q, called %v, want %v, %q", c.key, c.err, action, ui.status, called, c.action, c.status)
+		}
+	}
+}
+
+func TestTuiUpdateKeepsSelection(t *testing.T) {
+	ui := &tui{height: 20}
+	ui.update(tuiData{transactions: testTransactions()})
+	ui.key("down")
+	newer := n26.Transaction{ID: "d4", Amount: -3, MerchantName: "Bakery"}
+	ui.update(tuiData{transactions: append(n26.Transactions{newer}, testTransactions()...)})
+	if ui.cursor != 2 || ui.visible()[ui.cursor].ID != "b2" {
+		t.Errorf("got cursor %d, want b2 still selected", ui.cursor)
+	}
+	// a failed refresh keeps the data
+	ui.update(tuiData{err: errors.New("timeout")})
+	if len(ui.data.transactions) != 4 || ui.status != "Refresh failed: timeout" {
+		t.Errorf("got %d transactions, %q", len(ui.data.transactions), ui.status)
+	}
+	// the client logged in again with replaces the expired one
+	API := &n26.Client{}
+	ui.update(tuiData{transactions: testTransactions(), API: API})
+	if ui.API != API || ui.cursor != 1 {
+		t.Errorf("got client %p, cursor %d, want %p, 1", ui.API, ui.cursor, API)
+	}
+}
+
+func TestTuiSearch(t *testing.T) {
+	ui := &tui{height: 20}
+	ui.update(tuiData{transactions: testTransactions()})
+	ui.key("end")
+	for _, k := range []string{"/", "m", "ü", "x", "backspace", "enter"} {
+		ui.key(k)
+	}
+	if visible := ui.visible(); ui.search != "mü" || ui.searching || len(visible) != 1 || visible[0].ID != "b2" || ui.cursor != 0 {
+		t.Errorf("got search %q, %d visible, cursor %d, want b2", ui.search, len(visible), ui.cursor)
+	}
+	// amounts and categories are searched too
+	ui.search = "1500.00"
+	if visible := ui.visible(); len(visible) != 1 || visible[0].ID != "a1" {
+		t.Errorf("amount: got %d visible", len(visible))
+	}
+	ui.search = "food"
+	if visible := ui.visible(); len(visible) != 1 || visible[0].ID != "c3" {
+		t.Errorf("category: got %d visible", len(visible))
+	}
+	ui.key("esc")
+	if ui.search != "" || len(ui.visible()) != 3 {
+		t.Errorf("esc: got search %q, %d visible", ui.search, len(ui.visible()))
+	}
+	ui.key("/")
+	ui.key("esc")
+	if ui.searching || ui.search != "" {
+		t.Errorf("esc while typing: got %v, %q", ui.searching, ui.search)
+	}
+}
+
+func TestReadKeys(t *testing.T) {
+	// reads cutting characters in two
+	input := io.MultiReader(strings.NewReader("a\xc3"), strings.NewReader("\xa9\xe2\x82"), strings.NewReader("\xac\033[Aq"))
+	keys := make(chan string)
+	go readKeys(input, keys)
+	got := []string{}
+	for k := range keys {
+		got = append(got, k)
+	}
+	if want := []string{"a", "é", "€", "up", "q"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/guitmz/n26"
+	"github.com/guitmz/n26/statementpdf"
+	"github.com/urfave/cli"
+)
+
+var verifyCommand = cli.Command{
+	Name: "verify",
+	Usage: "reconcile a statement PDF with the transactions of its period and report mismatches. " +
+		"Reads the PDF named like the download command does, downloading it if missing",
+	ArgsUsage: "[statement ID]",
+	Flags:     statementFileFlags(),
+	Action: func(c *cli.Context) error {
+		ID := c.Args().First()
+		if ID == "" {
+			return cli.NewExitError("A statement ID must be given!", 1)
+		}
+		file, err := statementPath(c, ID)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		API, err := authentication()
+		check(err)
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			check(saveStatementPDF(API, ID, file))
+		}
+		statement, err := statementpdf.Open(file)
+		check(err)
+		if statement.From.IsZero() {
+			return cli.NewExitError(fmt.Sprintf("No statement period found in %s!", file), 1)
+		}
+		from := n26.TimeStamp{Time: statement.From.Add(-statementpdf.MaxBookingDelay)}
+		to := n26.TimeStamp{Time: statement.To.Add(statementpdf.MaxBookingDelay)}
+		transactions, err := windowTransactions(API, from, to)
+		check(err)
+		result := statement.Reconcile(*transactions)
+
+		fmt.Printf("Statement %s from %s to %s\n", ID, statement.From.Format("2006-01-02"), statement.To.Format("2006-01-02"))
+		fmt.Printf("Opening balance %.2f, closing balance %.2f\n", statement.Opening, statement.Closing)
+		balanced := statement.Check()
+		if balanced != nil {
+			fmt.Printf("The statement is inconsistent: %v\n", balanced)
+		}
+		fmt.Printf("%d of %d items match a transaction\n\n", result.Matched, len(statement.Items))
+		if result.Consistent() && balanced == nil {
+			return nil
+		}
+		data := [][]string{}
+		for _, item := range result.OnlyInStatement {
+			data = append(data, []string{"statement", item.Booked.Format("2006-01-02"),
+				strconv.FormatFloat(item.Amount, 'f', 2, 64), strings.Join(item.Description, " / ")})
+		}
+		for _, transaction := range result.OnlyInTransactions {
+			name := transaction.PartnerName
+			if name == "" {
+				name = transaction.MerchantName
+			}
+			data = append(data, []string{"transactions", transaction.VisibleTS.Format("2006-01-02"),
+				strconv.FormatFloat(transaction.Amount, 'f', 2, 64), name})
+		}
+		if len(data) > 0 {
+			NewTableWriter().WriteData([]string{"Only In", "Date", "Amount", "Description"}, data)
+		}
+		return cli.NewExitError("The statement does not match the transactions!", 1)
+	},
+}
+
+// The path of the statement PDF by the --out-dir and --template flags
+func statementPath(c *cli.Context, ID string) (string, error) {
+	downloader, err := statementDownloaderFromFlags(c)
+	if err != nil {
+		return "", err
+	}
+	return downloader.path(statementFileOfID(ID))
+}
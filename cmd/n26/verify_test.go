@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestStatementPath(t *testing.T) {
+	cases := []struct {
+		args []string
+		ID   string
+		want string
+	}{
+		{nil, "statement-2018-03", "statement-2018-03.pdf"},
+		{[]string{"--out-dir", "pdf", "--template", "{{.Year}}-{{.Month}}.pdf"}, "statement-2018-3",
+			filepath.Join("pdf", "2018-03.pdf")},
+		{[]string{"--template", "{{.Year}}.pdf"}, "statement-2018-03", "2018.pdf"},
+	}
+	for _, c := range cases {
+		runWithFlags(t, statementFileFlags(), c.args, func(ctx *cli.Context) {
+			got, err := statementPath(ctx, c.ID)
+			if err != nil || got != c.want {
+				t.Errorf("%v %s: got %q, %v, want %q", c.args, c.ID, got, err, c.want)
+			}
+		})
+	}
+	runWithFlags(t, statementFileFlags(), []string{"--template", "{{.Name}}.pdf"}, func(ctx *cli.Context) {
+		if _, err := statementPath(ctx, "statement-2018-03"); err == nil {
+			t.Error("unknown template field: got no error")
+		}
+	})
+}
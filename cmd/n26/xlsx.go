@@ -0,0 +1,440 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/guitmz/n26"
+)
+
+// Office Open XML content types and relationships
+const (
+	xlsxMainNamespace   = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelsNamespace   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xlsxPackageRels     = "http://schemas.openxmlformats.org/package/2006/relationships"
+	xlsxContentTypes    = "http://schemas.openxmlformats.org/package/2006/content-types"
+	xlsxContentTypeMain = "application/vnd.openxmlformats-officedocument.spreadsheetml"
+	// spreadsheet dates are days since this epoch
+	xlsxEpochDays = 25569
+)
+
+// A style of the cells of a workbook, the number format and font weight
+type xlsxStyle struct {
+	format string
+	bold   bool
+}
+
+// A workbook with typed cells: strings, float64 numbers and time.Time dates
+type xlsxWorkbook struct {
+	sheets []*xlsxSheet
+	styles []xlsxStyle
+}
+
+type xlsxSheet struct {
+	name string
+	rows [][]xlsxCell
+}
+
+type xlsxCell struct {
+	value interface{}
+	style int
+}
+
+// The built-in styles of every workbook
+const (
+	xlsxDefault = iota
+	xlsxHeader
+	xlsxDate
+)
+
+func newXlsxWorkbook() *xlsxWorkbook {
+	return &xlsxWorkbook{styles: []xlsxStyle{{}, {bold: true}, {format: "yyyy-mm-dd hh:mm"}}}
+}
+
+// Style returns the style with the number format, e.g. '#,##0.00'
+func (w *xlsxWorkbook) Style(format string) int {
+	for i, style := range w.styles {
+		if style.format == format && !style.bold {
+			return i
+		}
+	}
+	w.styles = append(w.styles, xlsxStyle{format: format})
+	return len(w.styles) - 1
+}
+
+// CurrencyStyle returns the style for amounts of the currency.
+func (w *xlsxWorkbook) CurrencyStyle(currency string) int {
+	symbols := map[string]string{"EUR": "€", "USD": "$", "GBP": "£", "CHF": "CHF"}
+	symbol, ok := symbols[currency]
+	if !ok {
+		symbol = currency
+	}
+	return w.Style(`#,##0.00 "` + symbol + `"`)
+}
+
+// AddSheet appends a worksheet with a bold header row.
+func (w *xlsxWorkbook) AddSheet(name string, header ...string) *xlsxSheet {
+	sheet := &xlsxSheet{name: name}
+	if len(header) > 0 {
+		cells := make([]xlsxCell, len(header))
+		for i, title := range header {
+			cells[i] = xlsxCell{title, xlsxHeader}
+		}
+		sheet.rows = append(sheet.rows, cells)
+	}
+	w.sheets = append(w.sheets, sheet)
+	return sheet
+}
+
+func (s *xlsxSheet) AddRow(cells ...xlsxCell) {
+	s.rows = append(s.rows, cells)
+}
+
+// Column name of the zero based index, e.g. 'AA' for 26
+func xlsxColumn(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// Spreadsheet serial number of the wall clock time
+func xlsxDateValue(t time.Time) float64 {
+	wallClock := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return float64(wallClock.Unix())/86400 + xlsxEpochDays
+}
+
+// Escape text for element content and attributes
+func xlsxEscape(s string) string {
+	buffer := &bytes.Buffer{}
+	xml.EscapeText(buffer, []byte(s))
+	return buffer.String()
+}
+
+// Column widths fitting the content
+func (s *xlsxSheet) widths() []int {
+	widths := []int{}
+	for _, row := range s.rows {
+		for i, cell := range row {
+			width := 14
+			switch value := cell.value.(type) {
+			case string:
+				width = utf8.RuneCountInString(value) + 2
+			case time.Time:
+				width = 18
+			}
+			if width > 50 {
+				width = 50
+			}
+			for len(widths) <= i {
+				widths = append(widths, 8)
+			}
+			if width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	return widths
+}
+
+func (s *xlsxSheet) write(out io.Writer) {
+	fmt.Fprintf(out, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+`<worksheet xmlns="%s">`, xlsxMainNamespace)
+	// keep the header visible when scrolling
+	fmt.Fprint(out, `<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	if widths := s.widths(); len(widths) > 0 {
+		fmt.Fprint(out, "<cols>")
+		for i, width := range widths {
+			fmt.Fprintf(out, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		fmt.Fprint(out, "</cols>")
+	}
+	fmt.Fprint(out, "<sheetData>")
+	for r, row := range s.rows {
+		fmt.Fprintf(out, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			reference := fmt.Sprintf("%s%d", xlsxColumn(c), r+1)
+			switch value := cell.value.(type) {
+			case string:
+				fmt.Fprintf(out, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, reference, cell.style, xlsxEscape(value))
+			case float64:
+				fmt.Fprintf(out, `<c r="%s" s="%d"><v>%s</v></c>`, reference, cell.style, strconv.FormatFloat(value, 'g', -1, 64))
+			case time.Time:
+				style := cell.style
+				if style == xlsxDefault {
+					style = xlsxDate
+				}
+				fmt.Fprintf(out, `<c r="%s" s="%d"><v>%s</v></c>`, reference, style, strconv.FormatFloat(xlsxDateValue(value), 'f', -1, 64))
+			}
+		}
+		fmt.Fprint(out, "</row>")
+	}
+	fmt.Fprint(out, "</sheetData></worksheet>")
+}
+
+func (w *xlsxWorkbook) writeStyles(out io.Writer) {
+	fmt.Fprintf(out, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+`<styleSheet xmlns="%s">`, xlsxMainNamespace)
+	// custom number formats are numbered from 164
+	formats := map[string]int{}
+	order := []string{}
+	for _, style := range w.styles {
+		if _, ok := formats[style.format]; style.format != "" && !ok {
+			formats[style.format] = 164 + len(order)
+			order = append(order, style.format)
+		}
+	}
+	if len(order) > 0 {
+		fmt.Fprintf(out, `<numFmts count="%d">`, len(order))
+		for _, format := range order {
+			fmt.Fprintf(out, `<numFmt numFmtId="%d" formatCode="%s"/>`, formats[format], xlsxEscape(format))
+		}
+		fmt.Fprint(out, "</numFmts>")
+	}
+	fmt.Fprint(out, `<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>`+
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>`+
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`+
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+	fmt.Fprintf(out, `<cellXfs count="%d">`, len(w.styles))
+	for _, style := range w.styles {
+		format, font := formats[style.format], 0
+		if style.bold {
+			font = 1
+		}
+		fmt.Fprintf(out, `<xf numFmtId="%d" fontId="%d" fillId="0" borderId="0" xfId="0" applyNumberFormat="%d" applyFont="%d"/>`,
+			format, font, boolInt(format != 0), boolInt(style.bold))
+	}
+	fmt.Fprint(out, `</cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// A file of the XLSX archive
+type xlsxPart struct {
+	name  string
+	write func(io.Writer)
+}
+
+// Write the workbook as XLSX file
+func (w *xlsxWorkbook) Write(target io.Writer) error {
+	archive := zip.NewWriter(target)
+	parts := []xlsxPart{
+		{"[Content_Types].xml", w.writeContentTypes},
+		{"_rels/.rels", func(out io.Writer) {
+			fmt.Fprintf(out, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+
+				`<Relationships xmlns="%s"><Relationship Id="rId1" Type="%s/officeDocument" Target="xl/workbook.xml"/></Relationships>`,
+				xlsxPackageRels, xlsxRelsNamespace)
+		}},
+		{"xl/workbook.xml", w.writeWorkbook},
+		{"xl/_rels/workbook.xml.rels", w.writeWorkbookRels},
+		{"xl/styles.xml", w.writeStyles},
+	}
+	for i, sheet := range w.sheets {
+		parts = append(parts, xlsxPart{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.write})
+	}
+	for _, part := range parts {
+		out, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		part.write(out)
+	}
+	return archive.Close()
+}
+
+func (w *xlsxWorkbook) writeContentTypes(out io.Writer) {
+	fmt.Fprintf(out, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+`<Types xmlns="%s">`, xlsxContentTypes)
+	fmt.Fprint(out, `<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`+
+		`<Default Extension="xml" ContentType="application/xml"/>`)
+	fmt.Fprintf(out, `<Override PartName="/xl/workbook.xml" ContentType="%s.sheet.main+xml"/>`, xlsxContentTypeMain)
+	fmt.Fprintf(out, `<Override PartName="/xl/styles.xml" ContentType="%s.styles+xml"/>`, xlsxContentTypeMain)
+	for i := range w.sheets {
+		fmt.Fprintf(out, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="%s.worksheet+xml"/>`, i+1, xlsxContentTypeMain)
+	}
+	fmt.Fprint(out, "</Types>")
+}
+
+func (w *xlsxWorkbook) writeWorkbook(out io.Writer) {
+	fmt.Fprintf(out, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+`<workbook xmlns="%s" xmlns:r="%s"><sheets>`,
+		xlsxMainNamespace, xlsxRelsNamespace)
+	for i, sheet := range w.sheets {
+		fmt.Fprintf(out, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(sheet.name), i+1, i+1)
+	}
+	fmt.Fprint(out, "</sheets></workbook>")
+}
+
+func (w *xlsxWorkbook) writeWorkbookRels(out io.Writer) {
+	fmt.Fprintf(out, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+`<Relationships xmlns="%s">`, xlsxPackageRels)
+	for i := range w.sheets {
+		fmt.Fprintf(out, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, xlsxRelsNamespace, i+1)
+	}
+	fmt.Fprintf(out, `<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/>`, len(w.sheets)+1, xlsxRelsNamespace)
+	fmt.Fprint(out, "</Relationships>")
+}
+
+type xlsxWriter struct {
+	out      io.Writer
+	from, to n26.TimeStamp
+}
+
+// NewXlsxWriter creates a writer for transactions as XLSX workbook with a
+// summary of the totals per category and one worksheet per month from the
+// start to the end of the time window, until now if it is open, even for
+// months without transactions. Months and times are those of the N26 time
+// zone, as in the other exports, whatever the display time zone.
+func NewXlsxWriter(target io.Writer, from, to n26.TimeStamp) *xlsxWriter {
+	return &xlsxWriter{target, from, to}
+}
+
+// The month of the time in the N26 time zone, e.g. 2018-03
+func xlsxMonth(t time.Time) string {
+	return t.In(n26.Location()).Format("2006-01")
+}
+
+type categoryTotal struct {
+	count            int
+	income, expenses float64
+}
+
+func (w *xlsxWriter) WriteTransactions(transactions *n26.Transactions) error {
+	workbook := newXlsxWorkbook()
+	currency := "EUR"
+	if len(*transactions) > 0 && (*transactions)[0].CurrencyCode != "" {
+		currency = (*transactions)[0].CurrencyCode
+	}
+	amount, number := workbook.CurrencyStyle(currency), workbook.Style("#,##0.00")
+	summary := workbook.AddSheet("Summary", "Category", "Transactions", "Income", "Expenses", "Total")
+
+	sorted := append(n26.Transactions{}, *transactions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].VisibleTS.Before(sorted[j].VisibleTS.Time) })
+	months := map[string]*xlsxSheet{}
+	if !w.from.IsZero() {
+		to := w.to.Time
+		if to.IsZero() {
+			to = time.Now()
+		}
+		from := w.from.In(n26.Location())
+		month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, n26.Location())
+		for ; xlsxMonth(month) <= xlsxMonth(to); month = month.AddDate(0, 1, 0) {
+			months[xlsxMonth(month)] = nil
+		}
+	}
+	for _, transaction := range sorted {
+		months[xlsxMonth(transaction.VisibleTS.Time)] = nil
+	}
+	names := []string{}
+	for month := range months {
+		names = append(names, month)
+	}
+	sort.Strings(names)
+	for _, month := range names {
+		months[month] = workbook.AddSheet(month, "Date", "Payee", "IBAN", "Category", "Reference", "Type",
+			"Amount", "Currency", "Original Amount", "Original Currency")
+	}
+
+	totals := map[string]*categoryTotal{}
+	for _, transaction := range sorted {
+		sheet := months[xlsxMonth(transaction.VisibleTS.Time)]
+		payee := transaction.PartnerName
+		if payee == "" {
+			payee = transaction.MerchantName
+		}
+		category := transaction.CategoryName()
+		if category == "" {
+			category = "Uncategorized"
+		}
+		originalAmount := xlsxCell{}
+		if transaction.IsForeignCurrency() {
+			originalAmount = xlsxCell{transaction.OriginalAmount, number}
+		}
+		sheet.AddRow(
+			xlsxCell{transaction.VisibleTS.In(n26.Location()), xlsxDate},
+			xlsxCell{payee, xlsxDefault},
+			xlsxCell{transaction.PartnerIban, xlsxDefault},
+			xlsxCell{category, xlsxDefault},
+			xlsxCell{transaction.ReferenceText, xlsxDefault},
+			xlsxCell{transaction.Type, xlsxDefault},
+			xlsxCell{transaction.Amount, amount},
+			xlsxCell{transaction.CurrencyCode, xlsxDefault},
+			originalAmount,
+			xlsxCell{transaction.OriginalCurrency, xlsxDefault},
+		)
+
+		total, ok := totals[category]
+		if !ok {
+			total = &categoryTotal{}
+			totals[category] = total
+		}
+		total.count++
+		if transaction.Amount > 0 {
+			total.income += transaction.Amount
+		} else {
+			total.expenses += transaction.Amount
+		}
+	}
+
+	categories := []string{}
+	for category := range totals {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	sum := categoryTotal{}
+	for _, category := range categories {
+		total := totals[category]
+		summary.AddRow(xlsxCell{category, xlsxDefault}, xlsxCell{float64(total.count), xlsxDefault},
+			xlsxCell{total.income, amount}, xlsxCell{total.expenses, amount}, xlsxCell{total.income + total.expenses, amount})
+		sum.count += total.count
+		sum.income += total.income
+		sum.expenses += total.expenses
+	}
+	summary.AddRow(xlsxCell{"Total", xlsxHeader}, xlsxCell{float64(sum.count), xlsxDefault},
+		xlsxCell{sum.income, amount}, xlsxCell{sum.expenses, amount}, xlsxCell{sum.income + sum.expenses, amount})
+	return workbook.Write(w.out)
+}
+
+// Write the balance as XLSX workbook
+func writeBalanceXlsx(target io.Writer, balance *n26.Balance) error {
+	workbook := newXlsxWorkbook()
+	amount := workbook.CurrencyStyle("EUR")
+	sheet := workbook.AddSheet("Balance", "IBAN", "BIC", "Available Balance", "Usable Balance")
+	sheet.AddRow(xlsxCell{balance.IBAN, xlsxDefault}, xlsxCell{balance.BIC, xlsxDefault},
+		xlsxCell{balance.AvailableBalance, amount}, xlsxCell{balance.UsableBalance, amount})
+	return workbook.Write(target)
+}
+
+// Write the spaces and their total balance as XLSX workbook
+func writeSpacesXlsx(target io.Writer, spaces *n26.Spaces) error {
+	workbook := newXlsxWorkbook()
+	amount := workbook.CurrencyStyle("EUR")
+	sheet := workbook.AddSheet("Spaces", "Name", "Balance")
+	for _, space := range spaces.Spaces {
+		sheet.AddRow(xlsxCell{space.Name, xlsxDefault}, xlsxCell{space.Balance.AvailableBalance, amount})
+	}
+	sheet.AddRow(xlsxCell{"Total", xlsxHeader}, xlsxCell{spaces.TotalBalance, amount})
+	return workbook.Write(target)
+}
+
+// Write a table as XLSX workbook with a sheet of the name
+func writeRowsXlsx(target io.Writer, name string, header []string, rows [][]string) error {
+	workbook := newXlsxWorkbook()
+	sheet := workbook.AddSheet(name, header...)
+	for _, row := range rows {
+		cells := make([]xlsxCell, len(row))
+		for i, text := range row {
+			cells[i] = xlsxCell{text, xlsxDefault}
+		}
+		sheet.AddRow(cells...)
+	}
+	return workbook.Write(target)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+	"github.com/urfave/cli"
+)
+
+// The parts of an XLSX file by name
+func readXlsx(t *testing.T, data []byte) (names []string, parts map[string][]byte) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts = map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, file.Name)
+		parts[file.Name] = content
+	}
+	return names, parts
+}
+
+// The cell texts and values of a worksheet by row
+func xlsxRows(t *testing.T, sheet []byte) [][]string {
+	var worksheet struct {
+		Rows []struct {
+			Cells []struct {
+				Reference string `xml:"r,attr"`
+				Text      string `xml:"is>t"`
+				Value     string `xml:"v"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(sheet, &worksheet); err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]string{}
+	for _, row := range worksheet.Rows {
+		cells := []string{}
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Reference+"="+cell.Text+cell.Value)
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
+
+func TestXlsxWriter(t *testing.T) {
+	transactions := testTransactions()
+	// months and times are those of N26, whatever the --timezone decoded them in
+	for i := range transactions {
+		transactions[i].VisibleTS.Time = transactions[i].VisibleTS.UTC()
+	}
+	from := n26.TimeStamp{Time: time.Date(2018, 2, 15, 0, 0, 0, 0, n26.Location())}
+	to := n26.TimeStamp{Time: time.Date(2018, 4, 2, 0, 0, 0, 0, n26.Location())}
+	buffer := &bytes.Buffer{}
+	if err := NewXlsxWriter(buffer, from, to).WriteTransactions(&transactions); err != nil {
+		t.Fatal(err)
+	}
+	names, parts := readXlsx(t, buffer.Bytes())
+	wantNames := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels",
+		"xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml", "xl/worksheets/sheet3.xml",
+		"xl/worksheets/sheet4.xml"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("got parts %v, want %v", names, wantNames)
+	}
+	for name, content := range parts {
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			_, err := decoder.Token()
+			if err != nil {
+				if err != io.EOF {
+					t.Errorf("%s: %v", name, err)
+				}
+				break
+			}
+		}
+	}
+	if workbook := string(parts["xl/workbook.xml"]); !strings.Contains(workbook, `<sheets><sheet name="Summary" sheetId="1" r:id="rId1"/><sheet name="2018-02" sheetId="2" r:id="rId2"/>`+
+		`<sheet name="2018-03" sheetId="3" r:id="rId3"/><sheet name="2018-04" sheetId="4" r:id="rId4"/></sheets>`) {
+		t.Errorf("unexpected sheets in %s", workbook)
+	}
+	if sheet := string(parts["xl/worksheets/sheet3.xml"]); !strings.Contains(sheet,
+		`<c r="B4" s="0" t="inlineStr"><is><t xml:space="preserve">Pizza &lt;Place&gt; &amp; &#34;Bar&#34;</t></is></c>`) {
+		t.Errorf("payee not escaped in %s", sheet)
+	}
+
+	cases := []struct {
+		part string
+		want [][]string
+	}{
+		{"xl/worksheets/sheet1.xml", [][]string{
+			{"A1=Category", "B1=Transactions", "C1=Income", "D1=Expenses", "E1=Total"},
+			{"A2=Food & Groceries", "B2=1", "C2=0", "D2=-45.9", "E2=-45.9"},
+			{"A3=Household & Utilities", "B3=1", "C3=0", "D3=-800", "E3=-800"},
+			{"A4=Income", "B4=1", "C4=1500", "D4=0", "E4=1500"},
+			{"A5=Total", "B5=3", "C5=1500", "D5=-845.9", "E5=654.1"},
+		}},
+		// months of the window without transactions
+		{"xl/worksheets/sheet2.xml", [][]string{
+			{"A1=Date", "B1=Payee", "C1=IBAN", "D1=Category", "E1=Reference", "F1=Type", "G1=Amount",
+				"H1=Currency", "I1=Original Amount", "J1=Original Currency"},
+		}},
+		{"xl/worksheets/sheet4.xml", [][]string{
+			{"A1=Date", "B1=Payee", "C1=IBAN", "D1=Category", "E1=Reference", "F1=Type", "G1=Amount",
+				"H1=Currency", "I1=Original Amount", "J1=Original Currency"},
+		}},
+		{"xl/worksheets/sheet3.xml", [][]string{
+			{"A1=Date", "B1=Payee", "C1=IBAN", "D1=Category", "E1=Reference", "F1=Type", "G1=Amount",
+				"H1=Currency", "I1=Original Amount", "J1=Original Currency"},
+			{"A2=43160", "B2=ACME GmbH", "C2=", "D2=Income", "E2=Salary: yes # no", "F2=CT", "G2=1500", "H2=EUR", "J2="},
+			{"A3=43161.375", "B3=Müller & Söhne", "C3=DE89370400440532013000", "D3=Household & Utilities",
+				"E3=Rent 03/2018\r\nflat 'A:B/C'", "F3=DT", "G3=-800", "H3=EUR", "J3="},
+			{"A4=43176.73819444445", "B4=Pizza <Place> & \"Bar\"", "C4=", "D4=Food & Groceries", "E4=", "F4=PT",
+				"G4=-45.9", "H4=EUR", "I4=-50", "J4=USD"},
+		}},
+	}
+	for _, c := range cases {
+		if got := xlsxRows(t, parts[c.part]); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got\n%q\nwant\n%q", c.part, got, c.want)
+		}
+	}
+}
+
+func TestXlsxColumn(t *testing.T) {
+	cases := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {51, "AZ"}, {52, "BA"}, {701, "ZZ"}, {702, "AAA"},
+	}
+	for _, c := range cases {
+		if got := xlsxColumn(c.index); got != c.want {
+			t.Errorf("%d: got %s, want %s", c.index, got, c.want)
+		}
+	}
+}
+
+func TestXlsxDateValue(t *testing.T) {
+	cases := []struct {
+		time time.Time
+		want float64
+	}{
+		{time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), 25569},
+		{time.Date(2018, 3, 1, 0, 0, 0, 0, n26.Location()), 43160},
+		// the wall clock time, not UTC
+		{time.Date(2018, 3, 1, 18, 0, 0, 0, n26.Location()), 43160.75},
+	}
+	for _, c := range cases {
+		if got := xlsxDateValue(c.time); got != c.want {
+			t.Errorf("%v: got %v, want %v", c.time, got, c.want)
+		}
+	}
+}
+
+func TestWriteRowsXlsx(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	if err := writeRowsXlsx(buffer, "Cards", []string{"ID", "Status"}, [][]string{{"c1", "M_ACTIVE"}}); err != nil {
+		t.Fatal(err)
+	}
+	_, parts := readXlsx(t, buffer.Bytes())
+	if workbook := string(parts["xl/workbook.xml"]); !strings.Contains(workbook, `<sheet name="Cards" sheetId="1" r:id="rId1"/>`) {
+		t.Errorf("unexpected sheets in %s", workbook)
+	}
+	want := [][]string{{"A1=ID", "B1=Status"}, {"A2=c1", "B2=M_ACTIVE"}}
+	if got := xlsxRows(t, parts["xl/worksheets/sheet1.xml"]); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestXlsxOutput(t *testing.T) {
+	cases := []struct {
+		globals, args []string
+		want          bool
+	}{
+		{[]string{"--output", "xlsx"}, nil, true},
+		{nil, []string{"xlsx"}, true},
+		{[]string{"--output", "xlsx"}, []string{"json"}, false},
+		{nil, nil, false},
+	}
+	for _, c := range cases {
+		runWithGlobals(t, c.globals, nil, c.args, func(ctx *cli.Context) {
+			if got := xlsxOutput(ctx); got != c.want {
+				t.Errorf("%v %v: got %v, want %v", c.globals, c.args, got, c.want)
+			}
+		})
+	}
+}
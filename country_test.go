@@ -0,0 +1,53 @@
+package n26
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCountry(t *testing.T) {
+	cases := []struct {
+		code   Country
+		alpha2 string
+		name   string
+		str    string
+	}{
+		{276, "DE", "Germany", "Germany"},
+		{250, "FR", "France", "France"},
+		{826, "GB", "United Kingdom", "United Kingdom"},
+		{999, "", "", "999"},
+	}
+	for _, c := range cases {
+		if got := c.code.Alpha2(); got != c.alpha2 {
+			t.Errorf("Alpha2(%d) = %q, want %q", c.code, got, c.alpha2)
+		}
+		if got := c.code.Name(); got != c.name {
+			t.Errorf("Name(%d) = %q, want %q", c.code, got, c.name)
+		}
+		if got := c.code.String(); got != c.str {
+			t.Errorf("String(%d) = %q, want %q", c.code, got, c.str)
+		}
+	}
+}
+
+func TestCountryByAlpha2(t *testing.T) {
+	c, ok := CountryByAlpha2("AT")
+	if !ok || c != 40 {
+		t.Errorf("CountryByAlpha2(AT) = %d, %v", c, ok)
+	}
+	if _, ok := CountryByAlpha2("XX"); ok {
+		t.Error("Expected XX to be unknown")
+	}
+}
+
+func TestCountryUnmarshal(t *testing.T) {
+	var tr struct {
+		MerchantCountry Country `json:"merchantCountry"`
+	}
+	if err := json.Unmarshal([]byte(`{"merchantCountry": 724}`), &tr); err != nil {
+		t.Fatal(err)
+	}
+	if tr.MerchantCountry.Alpha2() != "ES" {
+		t.Errorf("Unexpected country %v", tr.MerchantCountry)
+	}
+}
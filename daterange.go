@@ -0,0 +1,140 @@
+package n26
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateRange is a time window of whole calendar days in the time zone N26
+// uses, Europe/Berlin. A zero To leaves the range open towards now.
+type DateRange struct {
+	From TimeStamp
+	To   TimeStamp
+}
+
+const dateFormat = "2006-01-02"
+
+// StartOfDay returns the first instant of the Berlin calendar day of t.
+func StartOfDay(t time.Time) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// EndOfDay returns the last millisecond of the Berlin calendar day of t.
+func EndOfDay(t time.Time) time.Time {
+	return StartOfDay(t).AddDate(0, 0, 1).Add(-time.Millisecond)
+}
+
+// Days returns the range from the start of the first to the end of the last day.
+func Days(first, last time.Time) DateRange {
+	return DateRange{TimeStamp{StartOfDay(first)}, TimeStamp{EndOfDay(last)}}
+}
+
+// ParseDate parses a calendar date in the format yyyy-mm-dd as the start of that day in Berlin.
+func ParseDate(s string) (time.Time, error) {
+	day, err := time.ParseInLocation(dateFormat, s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected yyyy-mm-dd", s)
+	}
+	return day, nil
+}
+
+var relativeDuration = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+// ParseSince parses either a calendar date or a duration relative to now
+// like '30d', '2w', '6m' or '1y' into an open ended range starting at the
+// beginning of that day.
+func ParseSince(s string, now time.Time) (DateRange, error) {
+	match := relativeDuration.FindStringSubmatch(strings.ToLower(s))
+	if match == nil {
+		day, err := ParseDate(s)
+		if err != nil {
+			return DateRange{}, fmt.Errorf("invalid start %q, expected a date like 2018-03-01 or a duration like 30d, 2w, 6m or 1y", s)
+		}
+		return DateRange{From: TimeStamp{day}}, nil
+	}
+	n, _ := strconv.Atoi(match[1])
+	now = now.In(loc)
+	var start time.Time
+	switch match[2] {
+	case "d":
+		start = now.AddDate(0, 0, -n)
+	case "w":
+		start = now.AddDate(0, 0, -7*n)
+	case "m":
+		start = now.AddDate(0, -n, 0)
+	case "y":
+		start = now.AddDate(-n, 0, 0)
+	}
+	return DateRange{From: TimeStamp{StartOfDay(start)}}, nil
+}
+
+// ParseMonth parses a calendar month in the format yyyy-mm into the range of all its days.
+func ParseMonth(s string) (DateRange, error) {
+	first, err := time.ParseInLocation("2006-01", s, loc)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("invalid month %q, expected yyyy-mm", s)
+	}
+	return Days(first, first.AddDate(0, 1, -1)), nil
+}
+
+var quarterPeriod = regexp.MustCompile(`^(?:(\d{4})-)?q([1-4])$`)
+
+// ParsePeriod parses a named period relative to now: today, yesterday,
+// this-week, last-week, this-month, last-month, this-quarter, last-quarter,
+// this-year, last-year, a quarter q1 to q4 (the most recent one that has
+// started), a quarter of a given year like 2018-q3 or a year like 2018.
+// Weeks start on Monday.
+func ParsePeriod(s string, now time.Time) (DateRange, error) {
+	now = now.In(loc)
+	today := StartOfDay(now)
+	year, month, _ := today.Date()
+	monthStart := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	quarterStart := time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, loc)
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	weekStart := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+
+	switch s = strings.ToLower(s); s {
+	case "today":
+		return Days(today, today), nil
+	case "yesterday":
+		yesterday := today.AddDate(0, 0, -1)
+		return Days(yesterday, yesterday), nil
+	case "this-week":
+		return Days(weekStart, weekStart.AddDate(0, 0, 6)), nil
+	case "last-week":
+		return Days(weekStart.AddDate(0, 0, -7), weekStart.AddDate(0, 0, -1)), nil
+	case "this-month":
+		return Days(monthStart, monthStart.AddDate(0, 1, -1)), nil
+	case "last-month":
+		return Days(monthStart.AddDate(0, -1, 0), monthStart.AddDate(0, 0, -1)), nil
+	case "this-quarter":
+		return Days(quarterStart, quarterStart.AddDate(0, 3, -1)), nil
+	case "last-quarter":
+		return Days(quarterStart.AddDate(0, -3, 0), quarterStart.AddDate(0, 0, -1)), nil
+	case "this-year":
+		return Days(yearStart, yearStart.AddDate(1, 0, -1)), nil
+	case "last-year":
+		return Days(yearStart.AddDate(-1, 0, 0), yearStart.AddDate(0, 0, -1)), nil
+	}
+	if match := quarterPeriod.FindStringSubmatch(s); match != nil {
+		quarter, _ := strconv.Atoi(match[2])
+		start := time.Date(year, time.Month(3*quarter-2), 1, 0, 0, 0, 0, loc)
+		if match[1] != "" {
+			y, _ := strconv.Atoi(match[1])
+			start = time.Date(y, start.Month(), 1, 0, 0, 0, 0, loc)
+		} else if start.After(today) {
+			start = start.AddDate(-1, 0, 0)
+		}
+		return Days(start, start.AddDate(0, 3, -1)), nil
+	}
+	if y, err := strconv.Atoi(s); err == nil && len(s) == 4 {
+		start := time.Date(y, time.January, 1, 0, 0, 0, 0, loc)
+		return Days(start, start.AddDate(1, 0, -1)), nil
+	}
+	return DateRange{}, fmt.Errorf("invalid period %q, expected one of today, yesterday, this-week, last-week, "+
+		"this-month, last-month, this-quarter, last-quarter, this-year, last-year, q1-q4, yyyy-qN or yyyy", s)
+}
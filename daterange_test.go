@@ -0,0 +1,99 @@
+package n26
+
+import (
+	"testing"
+	"time"
+)
+
+// 2018-08-15 10:30 CEST, a Wednesday
+var testNow = time.Date(2018, 8, 15, 8, 30, 0, 0, time.UTC)
+
+func berlin(year int, month time.Month, day, hour, min, sec, msec int) time.Time {
+	return time.Date(year, month, day, hour, min, sec, msec*int(time.Millisecond), loc)
+}
+
+func checkRange(t *testing.T, name string, r DateRange, from, to time.Time) {
+	t.Helper()
+	if !r.From.Equal(from) || !r.To.Equal(to) {
+		t.Errorf("%s: got %v - %v, want %v - %v", name, r.From, r.To, from, to)
+	}
+}
+
+func TestDayBoundaries(t *testing.T) {
+	// Berlin day boundaries, including the days of the DST changes
+	checkRange(t, "winter", Days(time.Date(2018, 3, 1, 23, 30, 0, 0, time.UTC), time.Date(2018, 3, 1, 23, 30, 0, 0, time.UTC)),
+		time.Date(2018, 3, 1, 23, 0, 0, 0, time.UTC), time.Date(2018, 3, 2, 22, 59, 59, 999*int(time.Millisecond), time.UTC))
+	spring := berlin(2018, 3, 25, 12, 0, 0, 0)
+	checkRange(t, "spring", Days(spring, spring), berlin(2018, 3, 25, 0, 0, 0, 0), berlin(2018, 3, 25, 23, 59, 59, 999))
+	if d := EndOfDay(spring).Sub(StartOfDay(spring)); d != 23*time.Hour-time.Millisecond {
+		t.Errorf("Spring day should have 23 hours, got %v", d)
+	}
+	autumn := berlin(2018, 10, 28, 12, 0, 0, 0)
+	if d := EndOfDay(autumn).Sub(StartOfDay(autumn)); d != 25*time.Hour-time.Millisecond {
+		t.Errorf("Autumn day should have 25 hours, got %v", d)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	cases := map[string]time.Time{
+		"30d":        berlin(2018, 7, 16, 0, 0, 0, 0),
+		"2w":         berlin(2018, 8, 1, 0, 0, 0, 0),
+		"6m":         berlin(2018, 2, 15, 0, 0, 0, 0),
+		"1y":         berlin(2017, 8, 15, 0, 0, 0, 0),
+		"2018-03-01": berlin(2018, 3, 1, 0, 0, 0, 0),
+	}
+	for s, from := range cases {
+		r, err := ParseSince(s, testNow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRange(t, s, r, from, time.Time{})
+	}
+	if _, err := ParseSince("30x", testNow); err == nil {
+		t.Error("Expected an error for 30x")
+	}
+}
+
+func TestParseMonth(t *testing.T) {
+	r, err := ParseMonth("2018-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRange(t, "2018-02", r, berlin(2018, 2, 1, 0, 0, 0, 0), berlin(2018, 2, 28, 23, 59, 59, 999))
+	if _, err := ParseMonth("2018-13"); err == nil {
+		t.Error("Expected an error for 2018-13")
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	cases := []struct {
+		period   string
+		from, to time.Time
+	}{
+		{"today", berlin(2018, 8, 15, 0, 0, 0, 0), berlin(2018, 8, 15, 23, 59, 59, 999)},
+		{"yesterday", berlin(2018, 8, 14, 0, 0, 0, 0), berlin(2018, 8, 14, 23, 59, 59, 999)},
+		{"this-week", berlin(2018, 8, 13, 0, 0, 0, 0), berlin(2018, 8, 19, 23, 59, 59, 999)},
+		{"last-week", berlin(2018, 8, 6, 0, 0, 0, 0), berlin(2018, 8, 12, 23, 59, 59, 999)},
+		{"this-month", berlin(2018, 8, 1, 0, 0, 0, 0), berlin(2018, 8, 31, 23, 59, 59, 999)},
+		{"last-month", berlin(2018, 7, 1, 0, 0, 0, 0), berlin(2018, 7, 31, 23, 59, 59, 999)},
+		{"this-quarter", berlin(2018, 7, 1, 0, 0, 0, 0), berlin(2018, 9, 30, 23, 59, 59, 999)},
+		{"last-quarter", berlin(2018, 4, 1, 0, 0, 0, 0), berlin(2018, 6, 30, 23, 59, 59, 999)},
+		{"this-year", berlin(2018, 1, 1, 0, 0, 0, 0), berlin(2018, 12, 31, 23, 59, 59, 999)},
+		{"last-year", berlin(2017, 1, 1, 0, 0, 0, 0), berlin(2017, 12, 31, 23, 59, 59, 999)},
+		{"q1", berlin(2018, 1, 1, 0, 0, 0, 0), berlin(2018, 3, 31, 23, 59, 59, 999)},
+		{"Q3", berlin(2018, 7, 1, 0, 0, 0, 0), berlin(2018, 9, 30, 23, 59, 59, 999)},
+		{"q4", berlin(2017, 10, 1, 0, 0, 0, 0), berlin(2017, 12, 31, 23, 59, 59, 999)},
+		{"2016-q2", berlin(2016, 4, 1, 0, 0, 0, 0), berlin(2016, 6, 30, 23, 59, 59, 999)},
+		{"2016", berlin(2016, 1, 1, 0, 0, 0, 0), berlin(2016, 12, 31, 23, 59, 59, 999)},
+	}
+	for _, c := range cases {
+		r, err := ParsePeriod(c.period, testNow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRange(t, c.period, r, c.from, c.to)
+	}
+	if _, err := ParsePeriod("next-month", testNow); err == nil {
+		t.Error("Expected an error for next-month")
+	}
+}
@@ -0,0 +1,126 @@
+package n26
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TransactionFilter decides whether a transaction is selected.
+type TransactionFilter func(Transaction) bool
+
+// Filter returns the transactions selected by the filter, keeping their order.
+// A nil filter selects all transactions.
+func (t Transactions) Filter(filter TransactionFilter) Transactions {
+	if filter == nil {
+		return t
+	}
+	selected := Transactions{}
+	for _, transaction := range t {
+		if filter(transaction) {
+			selected = append(selected, transaction)
+		}
+	}
+	return selected
+}
+
+// All selects transactions matched by every filter. Without filters all transactions match.
+func All(filters ...TransactionFilter) TransactionFilter {
+	return func(t Transaction) bool {
+		for _, filter := range filters {
+			if !filter(t) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any selects transactions matched by at least one filter. Without filters all transactions match.
+func Any(filters ...TransactionFilter) TransactionFilter {
+	return func(t Transaction) bool {
+		for _, filter := range filters {
+			if filter(t) {
+				return true
+			}
+		}
+		return len(filters) == 0
+	}
+}
+
+// Not inverts a filter.
+func Not(filter TransactionFilter) TransactionFilter {
+	return func(t Transaction) bool {
+		return !filter(t)
+	}
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// ByMerchant selects transactions whose merchant name contains the text, ignoring case.
+func ByMerchant(text string) TransactionFilter {
+	return func(t Transaction) bool {
+		return containsFold(t.MerchantName, text)
+	}
+}
+
+// ByPartner selects transactions whose partner name or IBAN contains the text, ignoring case.
+func ByPartner(text string) TransactionFilter {
+	return func(t Transaction) bool {
+		return containsFold(t.PartnerName, text) || containsFold(t.PartnerIban, text)
+	}
+}
+
+// ByCategory selects transactions whose category contains the text, ignoring case.
+// N26 categories look like 'micro-v2-food-groceries', so 'groceries' is enough.
+func ByCategory(text string) TransactionFilter {
+	return func(t Transaction) bool {
+		return containsFold(t.Category, text)
+	}
+}
+
+// ByType selects transactions of the given N26 type, e.g. 'PT' for card payments.
+func ByType(transactionType string) TransactionFilter {
+	return func(t Transaction) bool {
+		return strings.EqualFold(t.Type, transactionType)
+	}
+}
+
+// ByCard selects transactions made with the card with the given ID.
+func ByCard(cardID string) TransactionFilter {
+	return func(t Transaction) bool {
+		return t.CardID == cardID
+	}
+}
+
+// ByReferenceText selects transactions whose reference text matches the expression.
+func ByReferenceText(expression *regexp.Regexp) TransactionFilter {
+	return func(t Transaction) bool {
+		return expression.MatchString(t.ReferenceText)
+	}
+}
+
+// ByAmount selects transactions whose absolute amount lies within [min, max].
+// Use math.Inf to leave one side open.
+func ByAmount(min, max float64) TransactionFilter {
+	return func(t Transaction) bool {
+		amount := math.Abs(t.Amount)
+		return amount >= min && amount <= max
+	}
+}
+
+// ByTime selects transactions visible within [from, to]. A zero time leaves
+// that side open.
+func ByTime(from, to time.Time) TransactionFilter {
+	return func(t Transaction) bool {
+		return (from.IsZero() || !t.VisibleTS.Before(from)) && (to.IsZero() || !t.VisibleTS.After(to))
+	}
+}
+
+// Pending selects transactions that are not booked yet.
+func Pending(t Transaction) bool {
+	return t.Pending
+}
@@ -0,0 +1,58 @@
+package n26
+
+import (
+	"math"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func march(day int) TimeStamp {
+	return TimeStamp{time.Date(2018, 3, day, 12, 0, 0, 0, loc)}
+}
+
+var filterTransactions = Transactions{
+	{ID: "1", Type: "PT", Amount: -12.5, MerchantName: "REWE Markt", Category: "micro-v2-food-groceries", CardID: "card-1", VisibleTS: march(17)},
+	{ID: "2", Type: "CT", Amount: 1500, PartnerName: "ACME GmbH", PartnerIban: "DE89370400440532013000", ReferenceText: "Salary 03/2018", VisibleTS: march(1)},
+	{ID: "3", Type: "DT", Amount: -49.99, PartnerName: "Stadtwerke", ReferenceText: "Invoice 4711", Pending: true, VisibleTS: march(10)},
+	{ID: "4", Type: "PT", Amount: -3.2, MerchantName: "Rewe To Go", Category: "micro-v2-food-groceries", CardID: "card-2", VisibleTS: march(20)},
+}
+
+func ids(t Transactions) string {
+	s := ""
+	for _, transaction := range t {
+		s += transaction.ID
+	}
+	return s
+}
+
+func TestFilter(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter TransactionFilter
+		want   string
+	}{
+		{"nil", nil, "1234"},
+		{"merchant", ByMerchant("rewe"), "14"},
+		{"partner name", ByPartner("acme"), "2"},
+		{"partner iban", ByPartner("DE8937"), "2"},
+		{"category", ByCategory("groceries"), "14"},
+		{"type", ByType("pt"), "14"},
+		{"card", ByCard("card-2"), "4"},
+		{"text", ByReferenceText(regexp.MustCompile(`^Invoice \d+$`)), "3"},
+		{"min", ByAmount(10, math.Inf(1)), "123"},
+		{"range", ByAmount(10, 50), "13"},
+		{"time", ByTime(march(5).Time, march(17).Time), "13"},
+		{"time open", ByTime(march(15).Time, time.Time{}), "14"},
+		{"pending", Pending, "3"},
+		{"all", All(ByMerchant("rewe"), ByCard("card-1")), "1"},
+		{"all empty", All(), "1234"},
+		{"any", Any(ByType("CT"), Pending), "23"},
+		{"not", Not(ByType("PT")), "23"},
+	}
+	for _, c := range cases {
+		if got := ids(filterTransactions.Filter(c.filter)); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package n26
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExchangeRates is a table of daily reference rates, quoted like the ECB
+// euro foreign exchange reference rates as units of foreign currency per euro.
+type ExchangeRates struct {
+	rates map[string][]dailyRate
+}
+
+type dailyRate struct {
+	day  time.Time
+	rate float64
+}
+
+const rateDateFormat = "2006-01-02"
+
+// Add a reference rate for the given currency and day.
+func (r *ExchangeRates) Add(currency string, day time.Time, rate float64) {
+	if r.rates == nil {
+		r.rates = map[string][]dailyRate{}
+	}
+	currency = strings.ToUpper(currency)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	rates := append(r.rates[currency], dailyRate{day, rate})
+	sort.Slice(rates, func(i, j int) bool { return rates[i].day.Before(rates[j].day) })
+	r.rates[currency] = rates
+}
+
+// Rate returns the most recent reference rate for the currency published on
+// or before the given day. Reference rates are not published on weekends and
+// holidays, so the rate of the previous business day is used for those.
+func (r *ExchangeRates) Rate(currency string, day time.Time) (float64, bool) {
+	currency = strings.ToUpper(currency)
+	if currency == "EUR" {
+		return 1, true
+	}
+	rates := r.rates[currency]
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].day.After(day) })
+	if i == 0 {
+		return 0, false
+	}
+	return rates[i-1].rate, true
+}
+
+// ReadExchangeRates reads reference rates either in the ECB XML format
+// (eurofxref-hist.xml) or in the ECB CSV format (eurofxref-hist.csv), which
+// has a 'Date' column followed by one column per currency.
+func ReadExchangeRates(r io.Reader) (*ExchangeRates, error) {
+	buffered := bufio.NewReader(r)
+	if bom, _ := buffered.Peek(3); string(bom) == "\xEF\xBB\xBF" {
+		buffered.Discard(3)
+	}
+	for {
+		b, err := buffered.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			buffered.Discard(1)
+		case '<':
+			return readExchangeRatesXML(buffered)
+		default:
+			return readExchangeRatesCSV(buffered)
+		}
+	}
+}
+
+func readExchangeRatesCSV(r io.Reader) (*ExchangeRates, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) < 2 || !strings.EqualFold(header[0], "date") {
+		return nil, errors.New("Exchange rates CSV must start with a 'Date' column")
+	}
+	rates := &ExchangeRates{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		day, err := time.Parse(rateDateFormat, record[0])
+		if err != nil {
+			return nil, err
+		}
+		for i, value := range record[1:] {
+			if i+1 >= len(header) || value == "" || value == "N/A" {
+				continue
+			}
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid rate for %s on %s: %v", header[i+1], record[0], err)
+			}
+			rates.Add(header[i+1], day, rate)
+		}
+	}
+	return rates, nil
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+func readExchangeRatesXML(r io.Reader) (*ExchangeRates, error) {
+	envelope := ecbEnvelope{}
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	rates := &ExchangeRates{}
+	for _, d := range envelope.Days {
+		day, err := time.Parse(rateDateFormat, d.Time)
+		if err != nil {
+			return nil, err
+		}
+		for _, rate := range d.Rates {
+			rates.Add(rate.Currency, day, rate.Rate)
+		}
+	}
+	return rates, nil
+}
+
+// IsForeignCurrency reports whether the transaction was made in a currency
+// other than the one of the account.
+func (t Transaction) IsForeignCurrency() bool {
+	return t.OriginalCurrency != "" && t.OriginalCurrency != t.CurrencyCode
+}
+
+// EffectiveRate is the exchange rate actually applied to a foreign currency
+// transaction, in units of the original currency per unit of account currency.
+func (t Transaction) EffectiveRate() float64 {
+	if !t.IsForeignCurrency() || t.Amount == 0 {
+		return 0
+	}
+	return math.Abs(t.OriginalAmount / t.Amount)
+}
+
+// ConversionCost compares a foreign currency transaction with the given
+// reference rate. The cost is in account currency and positive if the
+// conversion was worse for the account holder than the reference rate; the
+// markup is the cost relative to the amount at the reference rate.
+func (t Transaction) ConversionCost(referenceRate float64) (cost, markup float64) {
+	if !t.IsForeignCurrency() || referenceRate == 0 {
+		return 0, 0
+	}
+	fair := math.Copysign(math.Abs(t.OriginalAmount)/referenceRate, t.Amount)
+	cost = fair - t.Amount
+	if fair != 0 {
+		markup = cost / math.Abs(fair)
+	}
+	return cost, markup
+}
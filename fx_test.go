@@ -0,0 +1,77 @@
+package n26
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const ecbXML = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2018-03-16">
+			<Cube currency="USD" rate="1.2300"/>
+			<Cube currency="GBP" rate="0.8800"/>
+		</Cube>
+		<Cube time="2018-03-15">
+			<Cube currency="USD" rate="1.2350"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+const ecbCSV = `Date, USD, GBP,
+2018-03-16, 1.2300, 0.8800,
+2018-03-15, 1.2350, N/A,
+`
+
+func TestReadExchangeRates(t *testing.T) {
+	for name, input := range map[string]string{"xml": ecbXML, "csv": "\xEF\xBB\xBF" + ecbCSV} {
+		rates, err := ReadExchangeRates(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		cases := []struct {
+			currency string
+			day      time.Time
+			rate     float64
+			ok       bool
+		}{
+			{"USD", time.Date(2018, 3, 15, 12, 0, 0, 0, time.UTC), 1.235, true},
+			{"USD", time.Date(2018, 3, 16, 0, 0, 0, 0, time.UTC), 1.23, true},
+			// weekend falls back to Friday
+			{"usd", time.Date(2018, 3, 18, 0, 0, 0, 0, time.UTC), 1.23, true},
+			{"GBP", time.Date(2018, 3, 15, 0, 0, 0, 0, time.UTC), 0, false},
+			{"USD", time.Date(2018, 3, 14, 0, 0, 0, 0, time.UTC), 0, false},
+			{"EUR", time.Date(2018, 3, 14, 0, 0, 0, 0, time.UTC), 1, true},
+		}
+		for _, c := range cases {
+			rate, ok := rates.Rate(c.currency, c.day)
+			if rate != c.rate || ok != c.ok {
+				t.Errorf("%s: Rate(%s, %v) = %v, %v, want %v, %v", name, c.currency, c.day, rate, ok, c.rate, c.ok)
+			}
+		}
+	}
+}
+
+func TestConversionCost(t *testing.T) {
+	spending := Transaction{Amount: -100, CurrencyCode: "EUR", OriginalAmount: -105.84, OriginalCurrency: "USD"}
+	if rate := spending.EffectiveRate(); math.Abs(rate-1.0584) > 1e-9 {
+		t.Errorf("Unexpected effective rate %v", rate)
+	}
+	cost, markup := spending.ConversionCost(1.08)
+	if math.Abs(cost-2) > 1e-9 || math.Abs(markup-2.0/98) > 1e-9 {
+		t.Errorf("Unexpected cost %v and markup %v", cost, markup)
+	}
+
+	refund := Transaction{Amount: 95, CurrencyCode: "EUR", OriginalAmount: 105.84, OriginalCurrency: "USD"}
+	if cost, _ := refund.ConversionCost(1.08); math.Abs(cost-3) > 1e-9 {
+		t.Errorf("Unexpected refund cost %v", cost)
+	}
+
+	domestic := Transaction{Amount: -10, CurrencyCode: "EUR"}
+	if domestic.IsForeignCurrency() || domestic.EffectiveRate() != 0 {
+		t.Error("Domestic transaction must not be foreign currency")
+	}
+}
@@ -1,17 +1,20 @@
 module github.com/guitmz/n26
 
-go 1.14
+go 1.15
 
 require (
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/howeyc/gopass v0.0.0-20210920133722-c8aef6fb66ef
 	github.com/kr/pretty v0.1.0 // indirect
-	github.com/mattn/go-runewidth v0.0.14 // indirect
+	github.com/ledongthuc/pdf v0.0.0-20240201131950-da5b75280b06
+	github.com/mattn/go-runewidth v0.0.14
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/rivo/uniseg v0.4.4 // indirect
 	github.com/urfave/cli v1.22.12
 	golang.org/x/crypto v0.8.0 // indirect
 	golang.org/x/oauth2 v0.7.0
+	golang.org/x/term v0.7.0
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
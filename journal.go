@@ -0,0 +1,151 @@
+package n26
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Postings are aligned so amounts end in this column
+const journalAmountColumn = 60
+
+// CounterAccount returns the account of the first matching rule, or else an
+// account named after the category below Expenses or Income, depending on
+// the direction of the transaction.
+func (rules AccountRules) CounterAccount(t Transaction) string {
+	if account, ok := rules.Account(t); ok {
+		return account
+	}
+	root := "Expenses"
+	if t.Amount > 0 {
+		root = "Income"
+	}
+	return root + ":" + accountName(t.CategoryName())
+}
+
+// Turn a display name into an account name valid in ledger, hledger and
+// beancount, e.g. 'Food-Groceries' for 'Food & Groceries'
+func accountName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	if len(words) == 0 {
+		return "Uncategorized"
+	}
+	if !unicode.IsLetter([]rune(words[0])[0]) {
+		words[0] = "X" + words[0]
+	}
+	return strings.Join(words, "-")
+}
+
+func journalAmount(amount float64, currency string) string {
+	if amount == 0 {
+		// no negative zero
+		amount = 0
+	}
+	return strconv.FormatFloat(amount, 'f', 2, 64) + " " + currency
+}
+
+// A posting line with at least two spaces between account and amount
+func journalPosting(indent, account, amount string) string {
+	padding := journalAmountColumn - len(indent) - len(account) - len(amount)
+	if padding < 2 {
+		padding = 2
+	}
+	return indent + account + strings.Repeat(" ", padding) + amount
+}
+
+// The postings of a transaction, the counter-account in the original currency
+// at the converted total cost for foreign currency transactions
+func journalPostings(t Transaction, indent, account string, rules AccountRules) []string {
+	counter := journalAmount(-t.Amount, t.CurrencyCode)
+	if t.IsForeignCurrency() {
+		counter = journalAmount(math.Copysign(math.Abs(t.OriginalAmount), -t.Amount), t.OriginalCurrency) +
+			" @@ " + journalAmount(math.Abs(t.Amount), t.CurrencyCode)
+	}
+	return []string{
+		journalPosting(indent, rules.CounterAccount(t), counter),
+		journalPosting(indent, account, journalAmount(t.Amount, t.CurrencyCode)),
+	}
+}
+
+func journalPayee(t Transaction) string {
+	payee := t.PartnerName
+	if payee == "" {
+		payee = t.MerchantName
+	}
+	if payee == "" {
+		payee = t.CategoryName()
+	}
+	if payee == "" {
+		payee = t.Type
+	}
+	return journalText(payee)
+}
+
+func journalStatus(t Transaction) string {
+	if t.Pending {
+		return "!"
+	}
+	return "*"
+}
+
+func journalText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteLedger writes the statement as journal for ledger and hledger, with
+// the N26 transaction ID as n26_id tag for deduplication and the closing
+// balance as balance assertion.
+func (s AccountStatement) WriteLedger(w io.Writer, account string, rules AccountRules) error {
+	out := bufio.NewWriter(w)
+	for _, t := range s.Transactions {
+		fmt.Fprintf(out, "%s %s %s\n", t.VisibleTS.In(loc).Format(dateFormat), journalStatus(t), journalPayee(t))
+		fmt.Fprintf(out, "    ; n26_id: %s\n", t.ID)
+		if t.ReferenceText != "" {
+			fmt.Fprintf(out, "    ; %s\n", journalText(t.ReferenceText))
+		}
+		for _, posting := range journalPostings(t, "    ", account, rules) {
+			fmt.Fprintln(out, posting)
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "%s * Balance assertion\n", s.To.In(loc).Format(dateFormat))
+	fmt.Fprintln(out, journalPosting("    ", account, "0 "+s.Currency+" = "+journalAmount(s.Closing, s.Currency)))
+	return out.Flush()
+}
+
+var beancountEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func beancountString(s string) string {
+	return `"` + beancountEscaper.Replace(journalText(s)) + `"`
+}
+
+// WriteBeancount writes the statement as beancount ledger, with the N26
+// transaction ID as n26_id metadata for deduplication and the closing balance
+// as balance assertion. The accounts must be opened elsewhere.
+func (s AccountStatement) WriteBeancount(w io.Writer, account string, rules AccountRules) error {
+	out := bufio.NewWriter(w)
+	for _, t := range s.Transactions {
+		fmt.Fprintf(out, "%s %s %s %s\n", t.VisibleTS.In(loc).Format(dateFormat), journalStatus(t),
+			beancountString(journalPayee(t)), beancountString(t.ReferenceText))
+		fmt.Fprintf(out, "  n26_id: %s\n", beancountString(t.ID))
+		for _, posting := range journalPostings(t, "  ", account, rules) {
+			fmt.Fprintln(out, posting)
+		}
+		fmt.Fprintln(out)
+	}
+	// beancount checks balances at the start of the day
+	day := s.To.In(loc).AddDate(0, 0, 1).Format(dateFormat)
+	fmt.Fprintln(out, journalPosting(day+" balance ", account, journalAmount(s.Closing, s.Currency)))
+	return out.Flush()
+}
@@ -0,0 +1,72 @@
+package n26
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testJournalStatement() AccountStatement {
+	s := testAccountStatement()
+	s.Transactions = append(s.Transactions, Transaction{ID: "9f4b5d8b", Type: "PT", Amount: -12.34,
+		CurrencyCode: "EUR", OriginalAmount: -15, OriginalCurrency: "USD", MerchantName: "Diner",
+		Category: "micro-v2-bars-restaurants", VisibleTS: TimeStamp{time.Date(2018, 3, 20, 12, 0, 0, 0, loc)}})
+	return s
+}
+
+func TestWriteLedger(t *testing.T) {
+	rules, err := ParseAccountRules(strings.NewReader("merchant:rewe = Expenses:Groceries"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer := &bytes.Buffer{}
+	if err := testJournalStatement().WriteLedger(buffer, "Assets:N26", rules); err != nil {
+		t.Fatal(err)
+	}
+	output := buffer.String()
+	for _, expected := range []string{
+		"2018-03-01 * ACME GmbH\n    ; n26_id: 6c1f2a5e-2f1b-4a2e-9d2f-0f7b2b1c3d4e\n    ; Salary 03/2018\n",
+		"    Income:Uncategorized                        -1500.00 EUR\n    Assets:N26                                   1500.00 EUR\n",
+		"2018-03-17 ! REWE & Co\n",
+		"    Expenses:Groceries                             12.49 EUR\n",
+		"    Expenses:Bars-Restaurants         15.00 USD @@ 12.34 EUR\n",
+		"2018-03-31 * Balance assertion\n    Assets:N26                           0 EUR = 1537.51 EUR\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in\n%s", expected, output)
+		}
+	}
+}
+
+func TestWriteBeancount(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	if err := testJournalStatement().WriteBeancount(buffer, "Assets:N26", nil); err != nil {
+		t.Fatal(err)
+	}
+	output := buffer.String()
+	for _, expected := range []string{
+		"2018-03-01 * \"ACME GmbH\" \"Salary 03/2018\"\n  n26_id: \"6c1f2a5e-2f1b-4a2e-9d2f-0f7b2b1c3d4e\"\n",
+		"2018-03-17 ! \"REWE & Co\" \"\"\n",
+		"  Expenses:Uncategorized                           12.49 EUR\n",
+		"2018-04-01 balance Assets:N26                    1537.51 EUR\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in\n%s", expected, output)
+		}
+	}
+}
+
+func TestAccountName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Food & Groceries": "Food-Groceries",
+		"ATM":              "ATM",
+		"bars-restaurants": "Bars-Restaurants",
+		"24h shop":         "X24h-Shop",
+		"":                 "Uncategorized",
+	} {
+		if actual := accountName(name); actual != expected {
+			t.Errorf("accountName(%q) = %q, want %q", name, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package n26
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SWIFT MT940 limits lines to 65 characters and the :86: field to 6 lines
+const (
+	mt940LineLength = 65
+	mt940InfoLines  = 6
+	// length of the SEPA purpose and name subfields of :86:
+	mt940SubfieldLength = 27
+)
+
+// Replacements for characters outside of the SWIFT character set
+var swiftTransliteration = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ß", "ss",
+	"é", "e", "è", "e", "ê", "e", "á", "a", "à", "a", "â", "a", "ó", "o", "ò", "o",
+	"ô", "o", "ú", "u", "ù", "u", "í", "i", "ì", "i", "ç", "c", "ñ", "n", "&", "+",
+	"\r\n", " ", "\n", " ", "\r", " ",
+)
+
+// Convert the text to the SWIFT character set. The question mark is the
+// separator of the :86: subfields and thus replaced as well.
+func swiftText(s string) string {
+	s = swiftTransliteration.Replace(s)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("/-:().,'+ ", r):
+			return r
+		}
+		return '.'
+	}, s)
+}
+
+// Split a text into chunks of at most n characters
+func chunks(s string, n int) []string {
+	var result []string
+	for len(s) > n {
+		result = append(result, s[:n])
+		s = s[n:]
+	}
+	if s != "" {
+		result = append(result, s)
+	}
+	return result
+}
+
+func mt940Amount(amount float64) string {
+	return strings.Replace(strconv.FormatFloat(math.Abs(amount), 'f', 2, 64), ".", ",", 1)
+}
+
+func mt940CreditDebit(amount float64) string {
+	if amount < 0 {
+		return "D"
+	}
+	return "C"
+}
+
+// German business transaction code (GVC) and SWIFT transaction type for the N26 transaction types
+func mt940TransactionCode(t Transaction) (string, string) {
+	switch {
+	case t.Mcc == 6011:
+		return "083", "NMSC"
+	case t.Type == "PT" || t.Type == "AA":
+		return "106", "NMSC"
+	case t.Type == "DD":
+		return "105", "NDDT"
+	case t.Type == "PF":
+		return "808", "NCHG"
+	case t.Amount < 0:
+		return "116", "NTRF"
+	}
+	return "166", "NTRF"
+}
+
+var mt940PostingTexts = map[string]string{
+	"083": "BARGELDAUSZAHLUNG",
+	"105": "LASTSCHRIFT",
+	"106": "KARTENZAHLUNG",
+	"116": "UEBERWEISUNG",
+	"166": "GUTSCHRIFT",
+	"808": "GEBUEHREN",
+}
+
+var mt940PurposeFields = []string{"20", "21", "22", "23", "24", "25", "26", "27", "28", "29", "60", "61", "62", "63"}
+
+// Split a field into lines of at most n characters. A line starting with ':'
+// or '-' would start a new tag or end the message, so lines are cut before
+// another character instead, and never within a ?NN subfield separator.
+func mt940Lines(field string, n int) []string {
+	var lines []string
+	for len(field) > n {
+		cut := n
+		for cut > 1 && (strings.IndexByte(":-", field[cut]) >= 0 || field[cut-1] == '?' || field[cut-2] == '?') {
+			cut--
+		}
+		if cut == 1 {
+			// nothing but tag characters, replace the one starting the line
+			cut = n
+			field = field[:cut] + "." + field[cut+1:]
+		}
+		lines = append(lines, field[:cut])
+		field = field[cut:]
+	}
+	if field != "" {
+		lines = append(lines, field)
+	}
+	return lines
+}
+
+// The structured :86: field with SEPA subfields: ?00 posting text, ?20-?29
+// and ?60-?63 purpose, ?30 BIC, ?31 IBAN, ?32-?33 name of the counterparty.
+// The purpose is cut to the room the counterparty leaves in the 6 lines.
+func mt940Information(t Transaction) []string {
+	gvc, _ := mt940TransactionCode(t)
+	posting := gvc + "?00" + mt940PostingTexts[gvc]
+
+	counterparty := ""
+	if t.PartnerBic != "" {
+		counterparty += "?30" + swiftText(strings.ToUpper(t.PartnerBic))
+	}
+	if t.PartnerIban != "" {
+		counterparty += "?31" + swiftText(strings.ToUpper(strings.Replace(t.PartnerIban, " ", "", -1)))
+	}
+	name := t.PartnerName
+	if name == "" {
+		name = t.MerchantName
+	}
+	for i, n := range chunks(swiftText(name), mt940SubfieldLength) {
+		if i == 2 {
+			break
+		}
+		counterparty += fmt.Sprintf("?3%d%s", 2+i, n)
+	}
+
+	// the first line is shortened by the field tag, the others by cuts
+	// before tag characters
+	room := mt940InfoLines*mt940LineLength - len(":86:") - len(posting) - len(counterparty)
+	for {
+		info := posting
+		if t.ReferenceText != "" {
+			left := room
+			for i, p := range chunks("SVWZ+"+swiftText(t.ReferenceText), mt940SubfieldLength) {
+				if i == len(mt940PurposeFields) || left <= len("?20") {
+					break
+				}
+				if len("?20")+len(p) > left {
+					p = p[:left-len("?20")]
+				}
+				info += "?" + mt940PurposeFields[i] + p
+				left -= len("?20") + len(p)
+			}
+		}
+		lines := mt940Lines(":86:"+info+counterparty, mt940LineLength)
+		if len(lines) <= mt940InfoLines || room <= 0 {
+			if len(lines) > mt940InfoLines {
+				lines = lines[:mt940InfoLines]
+			}
+			return lines
+		}
+		room--
+	}
+}
+
+// WriteMT940 writes the statement as SWIFT MT940 customer statement message
+// with SEPA structured :86: fields, as imported by DATEV and most ERP systems.
+// The entries are written oldest first.
+func (s AccountStatement) WriteMT940(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(out, format+"\r\n", args...)
+	}
+	from, to := s.From.In(loc), s.To.In(loc)
+	line(":20:N26-%s", to.Format("060102"))
+	line(":25:%s", strings.Replace(s.Account.IBAN, " ", "", -1))
+	line(":28C:%05d/001", int(to.Month()))
+	line(":60F:%s%s%s%s", mt940CreditDebit(s.Opening), from.Format("060102"), s.Currency, mt940Amount(s.Opening))
+	entries := append(Transactions{}, s.Transactions...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].VisibleTS.Before(entries[j].VisibleTS.Time) })
+	for _, t := range entries {
+		day := t.VisibleTS.In(loc)
+		_, swiftType := mt940TransactionCode(t)
+		bankReference := swiftText(strings.Replace(t.ID, "-", "", -1))
+		if len(bankReference) > 16 {
+			bankReference = bankReference[:16]
+		}
+		line(":61:%s%s%s%s%sNONREF//%s", day.Format("060102"), day.Format("0102"),
+			mt940CreditDebit(t.Amount), mt940Amount(t.Amount), swiftType, bankReference)
+		for _, l := range mt940Information(t) {
+			line("%s", l)
+		}
+	}
+	line(":62F:%s%s%s%s", mt940CreditDebit(s.Closing), to.Format("060102"), s.Currency, mt940Amount(s.Closing))
+	line("-")
+	return out.Flush()
+}
@@ -0,0 +1,127 @@
+package n26
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteMT940(t *testing.T) {
+	statement := testAccountStatement()
+	statement.Transactions[1].ReferenceText = "Einkauf bei Müller & Söhne? Filiale 0815, Kassenbon 4711-0815-2018-03-17, vielen Dank für Ihren Einkauf"
+	buffer := &bytes.Buffer{}
+	if err := statement.WriteMT940(buffer); err != nil {
+		t.Fatal(err)
+	}
+	output := buffer.String()
+	if !strings.HasSuffix(output, "\r\n") {
+		t.Error("Lines must end with CRLF")
+	}
+	lines := strings.Split(strings.TrimSuffix(output, "\r\n"), "\r\n")
+	for _, line := range lines {
+		if len(line) > 65 {
+			t.Errorf("Line longer than 65 characters: %q", line)
+		}
+		if strings.Trim(line, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789/-?:().,'+ ") != "" {
+			t.Errorf("Characters outside of the SWIFT character set in %q", line)
+		}
+	}
+	expected := []string{
+		":20:N26-180331",
+		":25:DE74100110012620000000",
+		":28C:00003/001",
+		":60F:C180301EUR100,00",
+		":61:1803010301C1500,00NTRFNONREF//6c1f2a5e2f1b4a2e",
+		":86:166?00GUTSCHRIFT?20SVWZ+Salary 03/2018?30COBADEFFXXX?31DE8937",
+		"0400440532013000?32ACME GmbH",
+		":61:1803170317D12,49NMSCNONREF//7d2f3b6f3a2c5b3f",
+		":86:106?00KARTENZAHLUNG?20SVWZ+Einkauf bei Mueller + ?21Soehne. F",
+		"iliale 0815, Kasse?22nbon 4711-0815-2018-03-17, ?23vielen Dank fu",
+		"er Ihren Eink?24auf?32REWE + Co",
+	}
+	for i, line := range expected {
+		if i >= len(lines) || lines[i] != line {
+			t.Fatalf("Line %d: got %q, want %q\n%s", i, lines[i], line, output)
+		}
+	}
+	if last := lines[len(lines)-2:]; last[0] != ":62F:C180331EUR1537,51" || last[1] != "-" {
+		t.Errorf("Unexpected closing lines %q", last)
+	}
+}
+
+func TestMT940CounterpartyAndOrder(t *testing.T) {
+	statement := testAccountStatement()
+	statement.Transactions[0].ReferenceText = strings.Repeat("Rechnung 2018-0301 Kundennummer 4711 ", 12)
+	statement.Transactions[0].PartnerName = "ACME Anlagenbau und Vertrieb GmbH und Co KG"
+	// newest first, as N26 lists them
+	transactions := statement.Transactions
+	for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+		transactions[i], transactions[j] = transactions[j], transactions[i]
+	}
+	buffer := &bytes.Buffer{}
+	if err := statement.WriteMT940(buffer); err != nil {
+		t.Fatal(err)
+	}
+	var days []string
+	for _, line := range strings.Split(buffer.String(), "\r\n") {
+		if strings.HasPrefix(line, ":61:") {
+			days = append(days, line[4:10])
+		}
+	}
+	if strings.Join(days, " ") != "180301 180317 180331" {
+		t.Errorf("got entries of %v, want oldest first", days)
+	}
+	lines := mt940Information(transactions[2])
+	if len(lines) != 6 {
+		t.Errorf("got %d lines of :86:, want 6 with the purpose cut\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	info := strings.Join(lines, "")
+	if !strings.HasPrefix(info, ":86:166?00GUTSCHRIFT?20SVWZ+Rechnung") {
+		t.Errorf("got %s, want the purpose first", info)
+	}
+	if counterparty := "?30COBADEFFXXX?31DE89370400440532013000?32ACME Anlagenbau und Vertrie?33b GmbH und Co KG"; !strings.HasSuffix(info, counterparty) {
+		t.Errorf("got %s, want it to end with %s", info, counterparty)
+	}
+}
+
+func TestMT940InformationLines(t *testing.T) {
+	// The field starts with ':86:166?00GUTSCHRIFT?20SVWZ+', 28 characters, and
+	// the purpose continues after 22 characters with '?21', so the 35th
+	// character of the reference text is at byte 65, the start of line 2.
+	cases := []struct {
+		name      string
+		reference string
+	}{
+		{"colon at the line start", strings.Repeat("a", 34) + ":b"},
+		{"dash at the line start", strings.Repeat("a", 34) + "-b"},
+		{"separator at the line end", strings.Repeat("a", 33)},
+		{"tag characters", strings.Repeat(":-", 100)},
+		{"long text with dashes", strings.Repeat("Rechnung 2018-03-01: Kundennummer 4711-0815 ", 10)},
+	}
+	for _, c := range cases {
+		transaction := Transaction{Type: "CT", Amount: 1, ReferenceText: c.reference, PartnerName: "ACME GmbH",
+			PartnerIban: "DE89370400440532013000"}
+		lines := mt940Information(transaction)
+		if len(lines) > 6 {
+			t.Errorf("%s: got %d lines", c.name, len(lines))
+		}
+		for i, line := range lines {
+			if len(line) > 65 {
+				t.Errorf("%s: line longer than 65 characters: %q", c.name, line)
+			}
+			if i > 0 && (strings.HasPrefix(line, ":") || strings.HasPrefix(line, "-")) {
+				t.Errorf("%s: line %d starts with a tag character: %q", c.name, i+1, line)
+			}
+			if strings.HasSuffix(line, "?") || len(line) > 1 && line[len(line)-2] == '?' {
+				t.Errorf("%s: line %d cuts a subfield separator: %q", c.name, i+1, line)
+			}
+		}
+		info := strings.Join(lines, "")
+		if !strings.HasPrefix(info, ":86:166?00GUTSCHRIFT?20SVWZ+") || !strings.HasSuffix(info, "?31DE89370400440532013000?32ACME GmbH") {
+			t.Errorf("%s: got %s", c.name, info)
+		}
+		if len(c.reference) < 100 && !strings.Contains(strings.Replace(info[28:], "?21", "", 1), c.reference) {
+			t.Errorf("%s: got %s, want all of the reference", c.name, info)
+		}
+	}
+}
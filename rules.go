@@ -0,0 +1,80 @@
+package n26
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// AccountRule assigns an account, e.g. of a double entry bookkeeping, to the
+// transactions whose field matches the pattern.
+type AccountRule struct {
+	Field   string
+	Pattern *regexp.Regexp
+	Account string
+}
+
+// AccountRules are evaluated in order, the first matching rule wins.
+type AccountRules []AccountRule
+
+// The transaction fields rules can match, by name
+var ruleFields = map[string]func(Transaction) []string{
+	"category": func(t Transaction) []string { return []string{t.Category, t.CategoryName()} },
+	"merchant": func(t Transaction) []string { return []string{t.MerchantName} },
+	"partner":  func(t Transaction) []string { return []string{t.PartnerName, t.PartnerIban} },
+	"text":     func(t Transaction) []string { return []string{t.ReferenceText} },
+	"type":     func(t Transaction) []string { return []string{t.Type} },
+}
+
+// ParseAccountRules reads rules, one per line, in the format
+//
+//	<field>:<regular expression> = <account>
+//
+// where field is one of category, merchant, partner, text or type, e.g.
+//
+//	merchant:rewe|lidl = Expenses:Groceries
+//
+// Expressions ignore case. Empty lines and lines starting with # are skipped.
+func ParseAccountRules(r io.Reader) (AccountRules, error) {
+	rules := AccountRules{}
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		separator := strings.LastIndex(line, "=")
+		colon := strings.Index(line, ":")
+		if separator < 0 || colon < 0 || colon > separator {
+			return nil, fmt.Errorf("line %d: expected <field>:<expression> = <account>", number)
+		}
+		field := strings.ToLower(strings.TrimSpace(line[:colon]))
+		if _, ok := ruleFields[field]; !ok {
+			return nil, fmt.Errorf("line %d: unknown field %q", number, field)
+		}
+		pattern, err := regexp.Compile("(?i)" + strings.TrimSpace(line[colon+1:separator]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", number, err)
+		}
+		account := strings.TrimSpace(line[separator+1:])
+		if account == "" {
+			return nil, fmt.Errorf("line %d: missing account", number)
+		}
+		rules = append(rules, AccountRule{field, pattern, account})
+	}
+	return rules, scanner.Err()
+}
+
+// Account returns the account of the first rule matching the transaction.
+func (rules AccountRules) Account(t Transaction) (string, bool) {
+	for _, rule := range rules {
+		for _, value := range ruleFields[rule.Field](t) {
+			if value != "" && rule.Pattern.MatchString(value) {
+				return rule.Account, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,47 @@
+package n26
+
+import (
+	"strings"
+	"testing"
+)
+
+const testRules = `
+# groceries first
+merchant:rewe|lidl = Expenses:Groceries
+category:^Bars = Expenses:Eating Out
+partner:^DE89 = Income:Salary
+text:invoice \d+ = Expenses:Utilities
+type:pf = Expenses:Bank
+`
+
+func TestAccountRules(t *testing.T) {
+	rules, err := ParseAccountRules(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		transaction Transaction
+		account     string
+	}{
+		{Transaction{MerchantName: "REWE Markt", Category: "micro-v2-bars-restaurants"}, "Expenses:Groceries"},
+		{Transaction{MerchantName: "Cafe", Category: "micro-v2-bars-restaurants"}, "Expenses:Eating Out"},
+		{Transaction{PartnerName: "ACME", PartnerIban: "DE89370400440532013000"}, "Income:Salary"},
+		{Transaction{ReferenceText: "Invoice 4711"}, "Expenses:Utilities"},
+		{Transaction{Type: "PF"}, "Expenses:Bank"},
+		{Transaction{MerchantName: "Unknown"}, ""},
+	}
+	for _, c := range cases {
+		account, ok := rules.Account(c.transaction)
+		if account != c.account || ok != (c.account != "") {
+			t.Errorf("Account(%+v) = %q, %v, want %q", c.transaction, account, ok, c.account)
+		}
+	}
+}
+
+func TestAccountRulesInvalid(t *testing.T) {
+	for _, rule := range []string{"merchant rewe = Expenses", "colour:red = Expenses", "merchant:( = Expenses", "merchant:rewe ="} {
+		if _, err := ParseAccountRules(strings.NewReader(rule)); err == nil {
+			t.Errorf("Expected an error for %q", rule)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package n26
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column headers of the N26 CSV files, lower case. Older and newer exports
+// of the app and web app, in English and German.
+var smartCsvColumns = map[string]string{
+	"date":                         "date",
+	"booking date":                 "date",
+	"datum":                        "date",
+	"buchungsdatum":                "date",
+	"payee":                        "payee",
+	"partner name":                 "payee",
+	"empfänger":                    "payee",
+	"name des zahlungsbeteiligten": "payee",
+	"account number":               "iban",
+	"partner iban":                 "iban",
+	"kontonummer":                  "iban",
+	"iban des zahlungsbeteiligten": "iban",
+	"transaction type":             "type",
+	"type":                         "type",
+	"transaktionstyp":              "type",
+	"buchungstext":                 "type",
+	"payment reference":            "reference",
+	"verwendungszweck":             "reference",
+	"category":                     "category",
+	"kategorie":                    "category",
+	"amount (foreign currency)":    "originalAmount",
+	"original amount":              "originalAmount",
+	"betrag (fremdwährung)":        "originalAmount",
+	"originalbetrag":               "originalAmount",
+	"type foreign currency":        "originalCurrency",
+	"original currency":            "originalCurrency",
+	"fremdwährung":                 "originalCurrency",
+	"originalwährung":              "originalCurrency",
+	"exchange rate":                "exchangeRate",
+	"wechselkurs":                  "exchangeRate",
+}
+
+var smartCsvDateFormats = []string{dateFormat, "02.01.2006", "2006-01-02 15:04:05"}
+
+// ParseSmartStatementCsv reads the transactions of an N26 CSV file, as
+// retrieved by GetSmartStatementCsv or downloaded from the N26 web app.
+// Headers may be English or German, amounts may use a decimal point or comma.
+//
+// The files carry neither transaction IDs nor times, so the transactions get
+// IDs derived from their content, the same for the same transaction in
+// overlapping files, and are dated at midnight of their booking day.
+func ParseSmartStatementCsv(r io.Reader) (Transactions, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.TrimPrefix(content, []byte("\xEF\xBB\xBF"))
+	reader := csv.NewReader(bytes.NewReader(content))
+	// files saved by German spreadsheets are separated by semicolons
+	firstLine := content
+	if end := bytes.IndexByte(content, '\n'); end >= 0 {
+		firstLine = content[:end]
+	}
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %v", err)
+	}
+	columns, currency := map[string]int{}, "EUR"
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if column, ok := smartCsvColumns[name]; ok {
+			columns[column] = i
+		} else if strings.HasPrefix(name, "amount") || strings.HasPrefix(name, "betrag") {
+			columns["amount"] = i
+			if open, close := strings.Index(name, "("), strings.Index(name, ")"); open >= 0 && close > open {
+				currency = strings.ToUpper(name[open+1 : close])
+			}
+		}
+	}
+	for _, required := range []string{"date", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("not an N26 CSV file, no %s column", required)
+		}
+	}
+
+	transactions := Transactions{}
+	occurrences := map[string]int{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		t := Transaction{CurrencyCode: currency, ReferenceText: field("reference")}
+		if t.VisibleTS.Time, err = parseSmartCsvDate(field("date")); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if t.Amount, err = parseDecimal(field("amount")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount: %v", line, err)
+		}
+		if field("originalCurrency") != "" && field("originalAmount") != "" {
+			t.OriginalCurrency = field("originalCurrency")
+			if t.OriginalAmount, err = parseDecimal(field("originalAmount")); err != nil {
+				return nil, fmt.Errorf("line %d: invalid original amount: %v", line, err)
+			}
+			if t.ExchangeRate, err = parseDecimal(field("exchangeRate")); err != nil {
+				return nil, fmt.Errorf("line %d: invalid exchange rate: %v", line, err)
+			}
+		}
+		t.Type = smartCsvType(field("type"), t.Amount)
+		if t.Type == "PT" {
+			t.MerchantName = field("payee")
+		} else {
+			t.PartnerName = field("payee")
+			t.PartnerIban = field("iban")
+		}
+		t.Category = smartCsvCategory(field("category"))
+
+		// independent of the language and number format of the file,
+		// identical transactions are told apart by their order
+		key := fmt.Sprintf("%s\x1f%.2f\x1f%s\x1f%s\x1f%s", t.VisibleTS.Format(dateFormat), t.Amount,
+			field("payee"), field("iban"), t.ReferenceText)
+		t.ID = smartCsvID(key, occurrences[key])
+		occurrences[key]++
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+func parseSmartCsvDate(s string) (time.Time, error) {
+	for _, format := range smartCsvDateFormats {
+		if t, err := time.ParseInLocation(format, s, loc); err == nil {
+			return t.In(DisplayLocation), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// Parse a decimal number with either a point or a comma as decimal separator.
+// The other one, if present, is taken as thousands separator. A single comma
+// is a decimal comma, as N26 does not group thousands.
+func parseDecimal(s string) (float64, error) {
+	s = strings.Replace(strings.TrimSpace(s), " ", "", -1)
+	if s == "" {
+		return 0, nil
+	}
+	point, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	if comma > point {
+		s = strings.Replace(strings.Replace(s, ".", "", -1), ",", ".", 1)
+	} else if comma >= 0 {
+		s = strings.Replace(s, ",", "", -1)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// The N26 type code of the transaction type text of the CSV files
+func smartCsvType(text string, amount float64) string {
+	lower := strings.ToLower(text)
+	contains := func(words ...string) bool {
+		for _, word := range words {
+			if strings.Contains(lower, word) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case contains("mastercard", "card payment", "kartenzahlung", "presentment"):
+		return "PT"
+	case contains("direct debit", "lastschrift"):
+		return "DD"
+	case contains("fee", "gebühr"):
+		return "PF"
+	case contains("income", "incoming", "gutschrift"):
+		return "CT"
+	case contains("transfer", "überweisung") && amount > 0:
+		return "CT"
+	case contains("transfer", "überweisung"):
+		return "DT"
+	}
+	return text
+}
+
+// The category ID of the English display name, other names are kept
+func smartCsvCategory(name string) string {
+	for id, displayName := range categoryNames {
+		if strings.EqualFold(name, displayName) {
+			return categoryPrefix + id
+		}
+	}
+	return name
+}
+
+func smartCsvID(record string, occurrence int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s\x1f%d", record, occurrence)))
+	return "csv-" + hex.EncodeToString(sum[:16])
+}
+
+// Merge returns the transactions of all lists without duplicate IDs, newest first.
+func (t Transactions) Merge(others ...Transactions) Transactions {
+	merged, seen := Transactions{}, map[string]bool{}
+	for _, list := range append([]Transactions{t}, others...) {
+		for _, transaction := range list {
+			if transaction.ID != "" && seen[transaction.ID] {
+				continue
+			}
+			seen[transaction.ID] = true
+			merged = append(merged, transaction)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].VisibleTS.After(merged[j].VisibleTS.Time) })
+	return merged
+}
@@ -0,0 +1,122 @@
+package n26
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const englishSmartCsv = "\xEF\xBB\xBF" + `"Date","Payee","Account number","Transaction type","Payment reference","Category","Amount (EUR)","Amount (Foreign Currency)","Type Foreign Currency","Exchange Rate"
+"2018-03-17","REWE Markt","","MasterCard Payment","","Food & Groceries","-12.5","","",""
+"2018-03-16","Diner","","MasterCard Payment","","Bars & Restaurants","-12.34","-15.0","USD","1.2156"
+"2018-03-01","ACME GmbH","DE89370400440532013000","Income","Salary 03/2018","Income","1500.0","","",""
+"2018-03-01","Jane Doe","DE02120300000000202051","Outgoing Transfer","Rent","Household & Utilities","-750.0","","",""
+`
+
+const germanSmartCsv = `Buchungsdatum;Wertstellung;Name des Zahlungsbeteiligten;IBAN des Zahlungsbeteiligten;Buchungstext;Verwendungszweck;Kontoname;Betrag (EUR);Originalbetrag;Originalwährung;Wechselkurs
+17.03.2018;17.03.2018;REWE Markt;;Kartenzahlung;;Hauptkonto;-1.012,50;;;
+01.03.2018;01.03.2018;ACME GmbH;DE89370400440532013000;Gutschrift;Salary 03/2018;Hauptkonto;1500,00;;;
+01.03.2018;01.03.2018;ACME GmbH;DE89370400440532013000;Gutschrift;Salary 03/2018;Hauptkonto;1500,00;;;
+`
+
+func TestParseSmartStatementCsvEnglish(t *testing.T) {
+	transactions, err := ParseSmartStatementCsv(strings.NewReader(englishSmartCsv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 4 {
+		t.Fatalf("Expected 4 transactions, got %d", len(transactions))
+	}
+	card, foreign, income, transfer := transactions[0], transactions[1], transactions[2], transactions[3]
+	if card.Type != "PT" || card.MerchantName != "REWE Markt" || card.Amount != -12.5 || card.CurrencyCode != "EUR" ||
+		card.Category != "micro-v2-food-groceries" {
+		t.Errorf("Unexpected card payment %+v", card)
+	}
+	if !card.VisibleTS.Equal(time.Date(2018, 3, 17, 0, 0, 0, 0, loc)) {
+		t.Errorf("Unexpected date %v", card.VisibleTS)
+	}
+	if !foreign.IsForeignCurrency() || foreign.OriginalAmount != -15 || foreign.ExchangeRate != 1.2156 {
+		t.Errorf("Unexpected foreign currency payment %+v", foreign)
+	}
+	if income.Type != "CT" || income.PartnerName != "ACME GmbH" || income.PartnerIban != "DE89370400440532013000" ||
+		income.ReferenceText != "Salary 03/2018" {
+		t.Errorf("Unexpected income %+v", income)
+	}
+	if transfer.Type != "DT" || transfer.Amount != -750 {
+		t.Errorf("Unexpected transfer %+v", transfer)
+	}
+}
+
+func TestParseSmartStatementCsvGerman(t *testing.T) {
+	transactions, err := ParseSmartStatementCsv(strings.NewReader(germanSmartCsv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 3 {
+		t.Fatalf("Expected 3 transactions, got %d", len(transactions))
+	}
+	if transactions[0].Type != "PT" || transactions[0].Amount != -1012.5 {
+		t.Errorf("Unexpected card payment %+v", transactions[0])
+	}
+	if transactions[1].Type != "CT" || transactions[1].Amount != 1500 || transactions[1].ReferenceText != "Salary 03/2018" {
+		t.Errorf("Unexpected income %+v", transactions[1])
+	}
+	if transactions[1].ID == transactions[2].ID {
+		t.Error("Identical rows must get different IDs")
+	}
+	english, err := ParseSmartStatementCsv(strings.NewReader(englishSmartCsv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transactions[1].ID != english[2].ID {
+		t.Error("The same transaction must get the same ID in English and German files")
+	}
+}
+
+func TestParseSmartStatementCsvInvalid(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"Name,Value\nfoo,1\n",
+		"Date,Amount (EUR)\n17/03/2018,1\n",
+		"Date,Amount (EUR)\n2018-03-17,one\n",
+	} {
+		if _, err := ParseSmartStatementCsv(strings.NewReader(content)); err == nil {
+			t.Errorf("Expected an error for %q", content)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	for s, expected := range map[string]float64{
+		"-12.5":     -12.5,
+		"-12,50":    -12.5,
+		"1.234,56":  1234.56,
+		"1,234.56":  1234.56,
+		" 1 500,0 ": 1500,
+		"":          0,
+	} {
+		if actual, err := parseDecimal(s); err != nil || actual != expected {
+			t.Errorf("parseDecimal(%q) = %v, %v, want %v", s, actual, err, expected)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	first, err := ParseSmartStatementCsv(strings.NewReader(englishSmartCsv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ParseSmartStatementCsv(strings.NewReader(englishSmartCsv[:strings.Index(englishSmartCsv, `"2018-03-16"`)]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := second.Merge(first)
+	if len(merged) != len(first) {
+		t.Fatalf("Expected %d transactions, got %d", len(first), len(merged))
+	}
+	for i := 1; i < len(merged); i++ {
+		if merged[i].VisibleTS.After(merged[i-1].VisibleTS.Time) {
+			t.Errorf("Not sorted newest first: %v after %v", merged[i].VisibleTS, merged[i-1].VisibleTS)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package n26
+
+import "time"
+
+// AccountStatement is the account activity within a time window together
+// with the balances at its start and end.
+type AccountStatement struct {
+	Account      *Balance
+	Currency     string
+	From, To     time.Time
+	Opening      float64
+	Closing      float64
+	Transactions Transactions
+}
+
+// NewAccountStatement creates the statement for a time window. The
+// transactions must cover at least the time from the start of the window
+// until now, as the balances are reconstructed from the current one.
+func NewAccountStatement(account *Balance, transactions Transactions, from, to time.Time) AccountStatement {
+	return newStatement(account, account.AvailableBalance, transactions, from, to)
+}
+
+// NewBookedStatement creates the statement of the booked transactions of a
+// time window, like NewAccountStatement. Pending transactions are left out,
+// and as the available balance has them deducted already, out of the
+// balances as well.
+func NewBookedStatement(account *Balance, transactions Transactions, from, to time.Time) AccountStatement {
+	booked := Transactions{}
+	current := account.AvailableBalance
+	for _, transaction := range transactions {
+		if transaction.Pending {
+			current -= transaction.Amount
+		} else {
+			booked = append(booked, transaction)
+		}
+	}
+	return newStatement(account, current, booked, from, to)
+}
+
+func newStatement(account *Balance, current float64, transactions Transactions, from, to time.Time) AccountStatement {
+	opening, closing, within := transactions.Balances(current, from, to)
+	currency := "EUR"
+	if len(within) > 0 && within[0].CurrencyCode != "" {
+		currency = within[0].CurrencyCode
+	}
+	return AccountStatement{account, currency, from, to, opening, closing, within}
+}
+
+// Sum returns the sum of the amounts of the transactions.
+func (t Transactions) Sum() float64 {
+	var sum float64
+	for _, transaction := range t {
+		sum += transaction.Amount
+	}
+	return sum
+}
+
+// Balances reconstructs the balances at the start and at the end of a time
+// window from the current balance. The transactions must cover at least the
+// time from the start of the window until now. The transactions within the
+// window are returned as well.
+func (t Transactions) Balances(current float64, from, to time.Time) (opening, closing float64, within Transactions) {
+	closing = current
+	within = Transactions{}
+	for _, transaction := range t {
+		switch {
+		case transaction.VisibleTS.After(to):
+			closing -= transaction.Amount
+		case !transaction.VisibleTS.Before(from):
+			within = append(within, transaction)
+		}
+	}
+	return closing - within.Sum(), closing, within
+}
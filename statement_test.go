@@ -0,0 +1,47 @@
+package n26
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBalances(t *testing.T) {
+	day := func(d int) TimeStamp { return TimeStamp{time.Date(2018, 3, d, 12, 0, 0, 0, loc)} }
+	transactions := Transactions{
+		{ID: "before", Amount: 5, VisibleTS: day(1)},
+		{ID: "first", Amount: -10, VisibleTS: day(5)},
+		{ID: "second", Amount: 20, VisibleTS: day(10)},
+		{ID: "after", Amount: -7.5, VisibleTS: day(20)},
+	}
+	opening, closing, within := transactions.Balances(100, day(5).Time, day(15).Time)
+	if math.Abs(closing-107.5) > 1e-9 || math.Abs(opening-97.5) > 1e-9 {
+		t.Errorf("Unexpected balances %v, %v", opening, closing)
+	}
+	if len(within) != 2 || within[0].ID != "first" || within[1].ID != "second" {
+		t.Errorf("Unexpected transactions %+v", within)
+	}
+}
+
+func TestNewBookedStatement(t *testing.T) {
+	day := func(d int) TimeStamp { return TimeStamp{time.Date(2018, 3, d, 12, 0, 0, 0, loc)} }
+	transactions := Transactions{
+		{ID: "first", Amount: -10, VisibleTS: day(5)},
+		{ID: "pending", Amount: -30, VisibleTS: day(10), Pending: true},
+		{ID: "after", Amount: -7.5, VisibleTS: day(20)},
+		{ID: "pending after", Amount: -2.5, VisibleTS: day(21), Pending: true},
+	}
+	account := &Balance{AvailableBalance: 100}
+	// the available balance has the pending transactions deducted
+	statement := NewBookedStatement(account, transactions, day(5).Time, day(15).Time)
+	if math.Abs(statement.Closing-140) > 1e-9 || math.Abs(statement.Opening-150) > 1e-9 {
+		t.Errorf("Unexpected balances %v, %v", statement.Opening, statement.Closing)
+	}
+	if len(statement.Transactions) != 1 || statement.Transactions[0].ID != "first" {
+		t.Errorf("Unexpected transactions %+v", statement.Transactions)
+	}
+	all := NewAccountStatement(account, transactions, day(5).Time, day(15).Time)
+	if math.Abs(all.Closing-110) > 1e-9 || len(all.Transactions) != 2 {
+		t.Errorf("Unexpected statement %+v", all)
+	}
+}
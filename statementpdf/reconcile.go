@@ -0,0 +1,73 @@
+package statementpdf
+
+import (
+	"math"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+// MaxBookingDelay is the longest time between a transaction and its booking,
+// as card payments are booked up to a few days after they are made.
+const MaxBookingDelay = 7 * 24 * time.Hour
+
+// Reconciliation is the result of matching the items of a statement with transactions.
+type Reconciliation struct {
+	Matched            int
+	OnlyInStatement    []Item
+	OnlyInTransactions n26.Transactions
+}
+
+// Consistent reports whether every item matched a transaction and vice versa.
+func (r Reconciliation) Consistent() bool {
+	return len(r.OnlyInStatement) == 0 && len(r.OnlyInTransactions) == 0
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// Reconcile matches the items of the statement with transactions of the same
+// amount, preferring the closest in time, at most a week apart. Pending and
+// zero amount transactions are not part of statements and thus ignored, as
+// are unmatched transactions outside of the period of the statement. Pass
+// the transactions of some days around the period to match late bookings.
+func (s *Statement) Reconcile(transactions n26.Transactions) Reconciliation {
+	result := Reconciliation{}
+	candidates := n26.Transactions{}
+	for _, transaction := range transactions {
+		if !transaction.Pending && transaction.Amount != 0 {
+			candidates = append(candidates, transaction)
+		}
+	}
+	matched := make([]bool, len(candidates))
+	for _, item := range s.Items {
+		best, bestDistance := -1, time.Duration(math.MaxInt64)
+		for i, transaction := range candidates {
+			if matched[i] || cents(transaction.Amount) != cents(item.Amount) {
+				continue
+			}
+			day := n26.StartOfDay(transaction.VisibleTS.Time)
+			distance := item.Booked.Sub(day)
+			if distance < 0 {
+				distance = -distance
+			}
+			if distance <= MaxBookingDelay && distance < bestDistance {
+				best, bestDistance = i, distance
+			}
+		}
+		if best < 0 {
+			result.OnlyInStatement = append(result.OnlyInStatement, item)
+			continue
+		}
+		matched[best] = true
+		result.Matched++
+	}
+	within := n26.ByTime(s.From, s.To)
+	for i, transaction := range candidates {
+		if !matched[i] && within(transaction) {
+			result.OnlyInTransactions = append(result.OnlyInTransactions, transaction)
+		}
+	}
+	return result
+}
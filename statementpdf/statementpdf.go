@@ -0,0 +1,285 @@
+// Package statementpdf reads the monthly N26 account statements, as retrieved
+// by GetStatementPDF, into balances and line items.
+//
+// The text of the PDF is extracted line by line with ReadLines and
+// interpreted by Parse, which knows the German and English layout of the
+// statements: an item is described by the lines up to the one ending in its
+// booking date and amount, the balances are summarised at the end.
+package statementpdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guitmz/n26"
+	"github.com/ledongthuc/pdf"
+)
+
+// Statement is the content of a monthly statement.
+type Statement struct {
+	From, To           time.Time
+	Opening, Closing   float64
+	Incoming, Outgoing float64
+	Items              []Item
+}
+
+// Item is a line item of a statement.
+type Item struct {
+	Booked    time.Time
+	ValueDate time.Time
+	Amount    float64
+	// payee, transaction type, reference and further details, as printed
+	Description []string
+}
+
+// Payee returns the first line of the description.
+func (i Item) Payee() string {
+	if len(i.Description) == 0 {
+		return ""
+	}
+	return i.Description[0]
+}
+
+const (
+	datePattern   = `(\d{2}[./]\d{2}[./]\d{4})`
+	amountPattern = `([+\-−–]?\s?[\d.,]*\d\s?(?:€|EUR))`
+)
+
+var (
+	periodLine    = regexp.MustCompile(datePattern + `\s*(?:bis|until|to|-|–)\s*` + datePattern)
+	itemLine      = regexp.MustCompile(`^(.*?)\s*` + datePattern + `\s+` + amountPattern + `$`)
+	valueDateText = regexp.MustCompile(`(?i)(?:wertstellung|value date)\s*` + datePattern)
+	tableHeader   = regexp.MustCompile(`(?i)^(beschreibung|description)\s+(verbuchungsdatum|booking date)`)
+	balanceLine   = regexp.MustCompile(`^(.*?)\s*` + amountPattern + `$`)
+	// page numbers and the imprint at the bottom of every page
+	pageFooter = regexp.MustCompile(`(?i)^(\d+\s*/\s*\d+|(seite|page)\s+\d+.*|n26 bank (gmbh|ag|se)\b.*)$`)
+)
+
+// Summary lines with the balances, by their lower case label
+var balanceLabels = map[string]func(*Statement) *float64{
+	"alter kontostand":         func(s *Statement) *float64 { return &s.Opening },
+	"previous balance":         func(s *Statement) *float64 { return &s.Opening },
+	"old balance":              func(s *Statement) *float64 { return &s.Opening },
+	"ausgehende transaktionen": func(s *Statement) *float64 { return &s.Outgoing },
+	"outgoing transactions":    func(s *Statement) *float64 { return &s.Outgoing },
+	"eingehende transaktionen": func(s *Statement) *float64 { return &s.Incoming },
+	"incoming transactions":    func(s *Statement) *float64 { return &s.Incoming },
+	"dein neuer kontostand":    func(s *Statement) *float64 { return &s.Closing },
+	"neuer kontostand":         func(s *Statement) *float64 { return &s.Closing },
+	"your new balance":         func(s *Statement) *float64 { return &s.Closing },
+	"new balance":              func(s *Statement) *float64 { return &s.Closing },
+}
+
+func parseDate(s string) (time.Time, error) {
+	return time.ParseInLocation("02.01.2006", strings.Replace(s, "/", ".", -1), n26.Location())
+}
+
+// Parse an amount like '-1.234,56€' or '+1,234.56 €'. The last separator
+// is the decimal separator, if followed by one or two digits.
+func parseAmount(s string) (float64, error) {
+	s = strings.NewReplacer("€", "", "EUR", "", " ", "", "−", "-", "–", "-").Replace(s)
+	separators := strings.NewReplacer(".", "", ",", "")
+	decimal := strings.LastIndexAny(s, ".,")
+	if decimal >= 0 && len(s)-decimal-1 <= 2 {
+		s = separators.Replace(s[:decimal]) + "." + s[decimal+1:]
+	} else {
+		s = separators.Replace(s)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// Attach description lines to the item, taking value dates from them
+func (i *Item) describe(lines ...string) {
+	for _, line := range lines {
+		if match := valueDateText.FindStringSubmatch(line); match != nil {
+			if date, err := parseDate(match[1]); err == nil {
+				i.ValueDate = date
+				line = strings.TrimSpace(strings.Replace(line, match[0], "", 1))
+			}
+		}
+		if line != "" {
+			i.Description = append(i.Description, line)
+		}
+	}
+}
+
+// Parse interprets the text lines of a statement. Items are either printed
+// with the payee on the line of the booking date and amount, followed by the
+// details, or with all of the description before that line.
+func Parse(lines []string) (*Statement, error) {
+	statement := &Statement{}
+	var pending []string
+	// whether the pending lines continue the description of the last item
+	continued := false
+	flush := func() {
+		if continued {
+			statement.Items[len(statement.Items)-1].describe(pending...)
+		}
+		pending, continued = nil, false
+	}
+	balances := 0
+	for _, line := range lines {
+		if line == pageBreak {
+			flush()
+			continue
+		}
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" || pageFooter.MatchString(line) {
+			continue
+		}
+		if tableHeader.MatchString(line) {
+			flush()
+			continue
+		}
+		if statement.From.IsZero() {
+			if match := periodLine.FindStringSubmatch(line); match != nil {
+				from, fromErr := parseDate(match[1])
+				to, toErr := parseDate(match[2])
+				if fromErr == nil && toErr == nil {
+					statement.From, statement.To = from, n26.EndOfDay(to)
+					pending = nil
+					continue
+				}
+			}
+		}
+		if match := balanceLine.FindStringSubmatch(line); match != nil {
+			if field, ok := balanceLabels[strings.ToLower(strings.TrimRight(match[1], ":"))]; ok {
+				amount, err := parseAmount(match[2])
+				if err != nil {
+					return nil, fmt.Errorf("invalid balance %q: %v", line, err)
+				}
+				*field(statement) = amount
+				balances++
+				flush()
+				continue
+			}
+		}
+		if match := itemLine.FindStringSubmatch(line); match != nil {
+			booked, dateErr := parseDate(match[2])
+			amount, amountErr := parseAmount(match[3])
+			if dateErr == nil && amountErr == nil {
+				item := Item{Booked: booked, Amount: amount}
+				item.describe(match[1])
+				if len(item.Description) > 0 {
+					flush()
+					continued = true
+				} else {
+					item.describe(pending...)
+					pending = nil
+				}
+				statement.Items = append(statement.Items, item)
+				continue
+			}
+		}
+		pending = append(pending, line)
+	}
+	flush()
+	if len(statement.Items) == 0 && balances == 0 {
+		return nil, errors.New("no N26 statement found in the text")
+	}
+	return statement, nil
+}
+
+// Check verifies that the items add up to the difference of the balances.
+func (s *Statement) Check() error {
+	sum := 0.0
+	for _, item := range s.Items {
+		sum += item.Amount
+	}
+	if difference := s.Opening + sum - s.Closing; math.Abs(difference) >= 0.005 {
+		return fmt.Errorf("the items sum up to %.2f, but the balance changed by %.2f", sum, s.Closing-s.Opening)
+	}
+	return nil
+}
+
+// The line separating pages in the text of a PDF
+const pageBreak = "\f"
+
+// ReadLines extracts the text of a PDF as lines, page by page from top to
+// bottom. Pages are separated by a line with a form feed.
+func ReadLines(r io.ReaderAt, size int64) (lines []string, err error) {
+	// the PDF reader panics on malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			lines, err = nil, fmt.Errorf("reading PDF: %v", r)
+		}
+	}()
+	reader, err := pdf.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		if i > 1 {
+			lines = append(lines, pageBreak)
+		}
+		lines = append(lines, textLines(page.Content().Text)...)
+	}
+	return lines, nil
+}
+
+// Group the glyphs of a page into lines. Glyphs further apart than a fraction
+// of the font size are separated by a space.
+func textLines(glyphs []pdf.Text) []string {
+	sorted := append([]pdf.Text{}, glyphs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Y > sorted[j].Y })
+	var rows [][]pdf.Text
+	for _, glyph := range sorted {
+		if n := len(rows); n > 0 && math.Abs(rows[n-1][0].Y-glyph.Y) < glyph.FontSize/3 {
+			rows[n-1] = append(rows[n-1], glyph)
+		} else {
+			rows = append(rows, []pdf.Text{glyph})
+		}
+	}
+	lines := []string{}
+	for _, row := range rows {
+		sort.SliceStable(row, func(i, j int) bool { return row[i].X < row[j].X })
+		line := &strings.Builder{}
+		end := math.Inf(-1)
+		for _, glyph := range row {
+			if glyph.X-end > glyph.FontSize/5 {
+				line.WriteString(" ")
+			}
+			line.WriteString(glyph.S)
+			end = glyph.X + glyph.W
+		}
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return lines
+}
+
+// Read extracts and parses a statement PDF.
+func Read(r io.ReaderAt, size int64) (*Statement, error) {
+	lines, err := ReadLines(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(lines)
+}
+
+// Open reads the statement PDF file.
+func Open(name string) (*Statement, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Read(file, info.Size())
+}
@@ -0,0 +1,152 @@
+package statementpdf
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/guitmz/n26"
+)
+
+func date(day int) time.Time {
+	return time.Date(2018, 3, day, 0, 0, 0, 0, n26.Location())
+}
+
+// testdata/statement.pdf is a two page statement in the N26 layout with made up data
+func TestReadLines(t *testing.T) {
+	file, err := os.Open("testdata/statement.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := ReadLines(file, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[int]string{
+		0:  "Kontoauszug",
+		3:  "Beschreibung Verbuchungsdatum Betrag",
+		4:  "ACME GmbH 01.03.2018 +1.500,00€",
+		10: "Mastercard • Lebensmittel",
+		13: pageBreak,
+		24: "Dein neuer Kontostand +1.575,16€",
+	}
+	for i, line := range expected {
+		if i >= len(lines) || lines[i] != line {
+			t.Errorf("Expected line %d to be %q in %q", i, line, lines)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	statement, err := Open("testdata/statement.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statement.From.Equal(date(1)) || !statement.To.Equal(n26.EndOfDay(date(31))) {
+		t.Errorf("Unexpected period %v to %v", statement.From, statement.To)
+	}
+	if statement.Opening != 100 || statement.Closing != 1575.16 || statement.Incoming != 1500 || statement.Outgoing != -24.84 {
+		t.Errorf("Unexpected balances %+v", statement)
+	}
+	if len(statement.Items) != 3 {
+		t.Fatalf("Expected 3 items, got %+v", statement.Items)
+	}
+	rewe := statement.Items[1]
+	if rewe.Payee() != "REWE Markt GmbH" || rewe.Amount != -12.5 || !rewe.Booked.Equal(date(17)) || !rewe.ValueDate.Equal(date(16)) {
+		t.Errorf("Unexpected item %+v", rewe)
+	}
+	// the page footer and the header of the next page are not part of the description
+	if len(rewe.Description) != 2 || rewe.Description[1] != "Mastercard • Lebensmittel" {
+		t.Errorf("Unexpected description %q", rewe.Description)
+	}
+	if err := statement.Check(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseDescriptionFirst(t *testing.T) {
+	statement, err := Parse([]string{
+		"Statement",
+		"01.03.2018 until 31.03.2018",
+		"Description Booking Date Amount",
+		"ACME GmbH",
+		"Income",
+		"Value Date 01.03.2018 01.03.2018 +1,500.00€",
+		"REWE Markt",
+		"MasterCard Payment",
+		"Value Date 16.03.2018 17.03.2018 -12.50 €",
+		"Previous balance +100.00€",
+		"Your new balance +1,587.50€",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statement.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %+v", statement.Items)
+	}
+	rewe := statement.Items[1]
+	if rewe.Payee() != "REWE Markt" || len(rewe.Description) != 2 || rewe.Amount != -12.5 || !rewe.ValueDate.Equal(date(16)) {
+		t.Errorf("Unexpected item %+v", rewe)
+	}
+	if err := statement.Check(); err != nil {
+		t.Error(err)
+	}
+	statement.Closing = 1600
+	if err := statement.Check(); err == nil {
+		t.Error("Expected the balances not to add up")
+	}
+}
+
+func TestParseNoStatement(t *testing.T) {
+	if _, err := Parse([]string{"Invoice", "Total 12.50"}); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for s, expected := range map[string]float64{
+		"+1.500,00€": 1500,
+		"-12,50 €":   -12.5,
+		"+1,500.00€": 1500,
+		"−3.20€":     -3.2,
+		"1.500€":     1500,
+		"- 7 EUR":    -7,
+	} {
+		if actual, err := parseAmount(s); err != nil || actual != expected {
+			t.Errorf("parseAmount(%q) = %v, %v, want %v", s, actual, err, expected)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	statement := &Statement{From: date(1), To: n26.EndOfDay(date(31)), Items: []Item{
+		{Booked: date(1), Amount: 1500},
+		{Booked: date(17), Amount: -12.5},
+		{Booked: date(21), Amount: -12.34},
+	}}
+	at := func(day, hour int) n26.TimeStamp {
+		return n26.TimeStamp{Time: date(day).Add(time.Duration(hour) * time.Hour)}
+	}
+	result := statement.Reconcile(n26.Transactions{
+		{ID: "salary", Amount: 1500, VisibleTS: at(1, 9)},
+		// booked days after the payment
+		{ID: "rewe", Amount: -12.5, VisibleTS: at(15, 18)},
+		{ID: "rewe-earlier", Amount: -12.5, VisibleTS: at(2, 18)},
+		{ID: "pending", Amount: -5, VisibleTS: at(20, 12), Pending: true},
+		{ID: "february", Amount: -9.99, VisibleTS: n26.TimeStamp{Time: date(0)}},
+	})
+	if result.Matched != 2 || result.Consistent() {
+		t.Errorf("Unexpected reconciliation %+v", result)
+	}
+	if len(result.OnlyInStatement) != 1 || result.OnlyInStatement[0].Amount != -12.34 {
+		t.Errorf("Unexpected items only in the statement %+v", result.OnlyInStatement)
+	}
+	if len(result.OnlyInTransactions) != 1 || result.OnlyInTransactions[0].ID != "rewe-earlier" {
+		t.Errorf("Unexpected transactions only in the API %+v", result.OnlyInTransactions)
+	}
+}
@@ -4,15 +4,51 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	// Europe/Berlin must be available even without a system time zone database
+	_ "time/tzdata"
 )
 
+// TimeStamp is a point in time as used by the N26 API.
+//
+// N26 encodes time stamps as milliseconds since the epoch of the wall clock
+// time in Berlin, i.e. as if Europe/Berlin local time was UTC. TimeStamp
+// converts from and to that representation, so the embedded time.Time always
+// holds the correct instant. After decoding it is in DisplayLocation.
 type TimeStamp struct {
 	time.Time
 }
 
 const millisAsNanos = int64(time.Millisecond)
 
-var loc, _ = time.LoadLocation("Europe/Berlin")
+// The time zone N26 uses for its time stamps and calendar days
+var loc = mustLoadLocation("Europe/Berlin")
+
+// DisplayLocation is the location decoded time stamps are converted to.
+// It only changes how times are displayed, not the instant they represent.
+var DisplayLocation = loc
+
+func mustLoadLocation(name string) *time.Location {
+	l, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// Convert N26 milliseconds to the instant they represent
+func fromN26Millis(millis int64) time.Time {
+	wall := time.Unix(0, millis*millisAsNanos).UTC()
+	return time.Date(wall.Year(), wall.Month(), wall.Day(),
+		wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+}
+
+// Convert an instant to N26 milliseconds
+func toN26Millis(t time.Time) int64 {
+	wall := t.In(loc)
+	return time.Date(wall.Year(), wall.Month(), wall.Day(),
+		wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC).UnixNano() / millisAsNanos
+}
 
 func (ts *TimeStamp) UnmarshalJSON(b []byte) (err error) {
 	s := strings.Trim(string(b), "\"")
@@ -23,14 +59,30 @@ func (ts *TimeStamp) UnmarshalJSON(b []byte) (err error) {
 	if err != nil {
 		return
 	}
-	//Adjust the timezone information as timestamp is given in local TZ
-	unadjusted := time.Unix(value/1000, (value%1000)*millisAsNanos).In(loc)
-	_, offset := unadjusted.Zone()
-	ts.Time = unadjusted.Add(time.Duration(-offset) * time.Second)
+	*ts = TimeStampFromMillis(value)
 	return
 }
 
-// convert the timestamp to an integer - millis since epoch
+// TimeStampFromMillis converts milliseconds as N26 returns them, e.g. in fields
+// decoded as plain integers, to a time stamp in DisplayLocation.
+func TimeStampFromMillis(millis int64) TimeStamp {
+	return TimeStamp{fromN26Millis(millis).In(DisplayLocation)}
+}
+
+// MarshalJSON encodes the time stamp like N26 does. Zero time stamps are encoded as null.
+func (ts TimeStamp) MarshalJSON() ([]byte, error) {
+	if ts.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.FormatInt(ts.AsMillis(), 10)), nil
+}
+
+// convert the timestamp to an integer - millis since epoch in the N26 representation
 func (ts *TimeStamp) AsMillis() int64 {
-	return ts.UnixNano() / int64(time.Millisecond)
+	return toN26Millis(ts.Time)
+}
+
+// Location returns the time zone N26 uses for its time stamps and calendar days, Europe/Berlin.
+func Location() *time.Location {
+	return loc
 }
@@ -2,6 +2,7 @@ package n26
 
 import (
 	"encoding/json"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -52,3 +53,72 @@ func TestUnmarshalError(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalDST(t *testing.T) {
+	cases := []struct {
+		name string
+		wall time.Time
+		want time.Time
+	}{
+		// N26 millis encode the Berlin wall clock as if it was UTC
+		{"winter", time.Date(2018, 1, 10, 12, 0, 0, 0, time.UTC), time.Date(2018, 1, 10, 11, 0, 0, 0, time.UTC)},
+		{"summer", time.Date(2018, 7, 10, 12, 0, 0, 0, time.UTC), time.Date(2018, 7, 10, 10, 0, 0, 0, time.UTC)},
+		{"before spring forward", time.Date(2018, 3, 25, 1, 45, 0, 0, time.UTC), time.Date(2018, 3, 25, 0, 45, 0, 0, time.UTC)},
+		{"after spring forward", time.Date(2018, 3, 25, 3, 0, 0, 0, time.UTC), time.Date(2018, 3, 25, 1, 0, 0, 0, time.UTC)},
+		{"before fall back", time.Date(2018, 10, 28, 1, 59, 0, 0, time.UTC), time.Date(2018, 10, 27, 23, 59, 0, 0, time.UTC)},
+		{"after fall back", time.Date(2018, 10, 28, 3, 0, 0, 0, time.UTC), time.Date(2018, 10, 28, 2, 0, 0, 0, time.UTC)},
+		{"new year", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 23, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		millis := c.wall.UnixNano() / int64(time.Millisecond)
+		ts := TimeStamp{}
+		if err := ts.UnmarshalJSON([]byte(strconv.FormatInt(millis, 10))); err != nil {
+			t.Fatal(err)
+		}
+		if !ts.Equal(c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, ts.UTC(), c.want)
+		}
+		if ts.AsMillis() != millis {
+			t.Errorf("%s: round trip gives %d, want %d", c.name, ts.AsMillis(), millis)
+		}
+		if ts.Location() != DisplayLocation {
+			t.Errorf("%s: unexpected location %v", c.name, ts.Location())
+		}
+		if converted := TimeStampFromMillis(millis); converted != ts {
+			t.Errorf("%s: converted to %v, want %v", c.name, converted, ts)
+		}
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	a := TestData{}
+	if err := json.Unmarshal([]byte(test), &a); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"Time":1521308624123,"Other":""}` {
+		t.Errorf("Unexpected JSON %s", b)
+	}
+	b, err = json.Marshal(TestData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"Time":null,"Other":""}` {
+		t.Errorf("Unexpected JSON for zero time stamp %s", b)
+	}
+}
+
+func TestDisplayLocation(t *testing.T) {
+	defer func(l *time.Location) { DisplayLocation = l }(DisplayLocation)
+	DisplayLocation = time.UTC
+	a := TestData{}
+	if err := json.Unmarshal([]byte(test), &a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Time.Location() != time.UTC || !a.Time.Equal(testTime) {
+		t.Errorf("Expected %v in UTC, got %v", testTime, a.Time)
+	}
+}